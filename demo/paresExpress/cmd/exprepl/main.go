@@ -0,0 +1,307 @@
+// Exprepl is an interactive calculator for expr expressions.
+//
+//	> r = 2
+//	> pi = 3.14159
+//	> pi * pow(r, 2)
+//	12.56636
+//
+// Variables assigned with name = expr persist across lines, and the last
+// result is available as ans. Lines starting with ':' are commands; type
+// :help for a list. On a terminal, lines can be edited in place and
+// earlier lines recalled with the arrow keys; history is kept on disk.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/binarycoder777/mini-go-demo/demo/paresExpress/expr"
+	"golang.org/x/term"
+)
+
+const maxHistory = 1000
+
+var historyFile = flag.String("history", defaultHistoryFile(), "file to keep input history in")
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".exprepl_history")
+}
+
+func main() {
+	flag.Parse()
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// Input is piped: no line editing, no prompt, no history.
+		s := &session{env: expr.Env{}, out: os.Stdout}
+		in := bufio.NewScanner(os.Stdin)
+		for in.Scan() {
+			if s.exec(in.Text()) {
+				return
+			}
+		}
+		return
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "> ")
+	h := loadHistory(*historyFile)
+	defer h.Close()
+	t.History = h
+
+	s := &session{env: expr.Env{}, out: t}
+	for {
+		line, err := t.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(t, err)
+			}
+			return
+		}
+		if s.exec(line) {
+			return
+		}
+	}
+}
+
+// A session holds the variables assigned so far.
+type session struct {
+	env expr.Env
+	out io.Writer
+}
+
+var resultFormat = expr.NumberFormat{Notation: 'g', Precision: -1}
+
+// exec runs one line of input and reports whether the user asked to quit.
+func (s *session) exec(line string) (quit bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	if strings.HasPrefix(line, ":") {
+		cmd, arg, _ := strings.Cut(line[1:], " ")
+		return s.command(cmd, strings.TrimSpace(arg))
+	}
+
+	target := expr.Var("ans")
+	if name, rhs, ok := strings.Cut(line, "="); ok && isIdent(strings.TrimSpace(name)) {
+		target, line = expr.Var(strings.TrimSpace(name)), rhs
+	}
+	e, ok := s.parse(line)
+	if !ok {
+		return false
+	}
+	v, err := expr.Evaluate(e, s.env)
+	if err != nil {
+		fmt.Fprintln(s.out, "error:", err)
+		return false
+	}
+	s.env[target] = v
+	fmt.Fprintln(s.out, resultFormat.Format(v))
+	return false
+}
+
+func (s *session) command(cmd, arg string) (quit bool) {
+	switch cmd {
+	case "q", "quit", "exit":
+		return true
+	case "vars":
+		names := make([]string, 0, len(s.env))
+		for v := range s.env {
+			names = append(names, string(v))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(s.out, "%s = %s\n", name, resultFormat.Format(s.env[expr.Var(name)]))
+		}
+	case "simplify":
+		if e, ok := s.parse(arg); ok {
+			fmt.Fprintln(s.out, expr.Normalize(expr.PartialEval(e, s.env)))
+		}
+	case "ast":
+		if e, ok := s.parse(arg); ok {
+			printTree(s.out, e, "")
+		}
+	case "plot", "svg":
+		s.plot(cmd, arg)
+	case "help":
+		fmt.Fprint(s.out, `name = expr     evaluate expr and assign it to name
+expr            evaluate expr and assign it to ans
+:vars           list assigned variables
+:simplify expr  substitute assigned variables and normalize expr
+:ast expr       print the syntax tree of expr
+:plot a b expr  chart expr for x from a to b
+:svg f a b expr write the chart of expr to the SVG file f
+:quit           leave
+`)
+	default:
+		fmt.Fprintf(s.out, "unknown command :%s (try :help)\n", cmd)
+	}
+	return false
+}
+
+// plot handles ":plot from to expr" and ":svg file from to expr".
+func (s *session) plot(cmd, arg string) {
+	var file string
+	if cmd == "svg" {
+		file, arg, _ = strings.Cut(arg, " ")
+	}
+	fields := strings.SplitN(arg, " ", 3)
+	if len(fields) < 3 {
+		fmt.Fprintf(s.out, "usage: :%s from to expr\n", cmd)
+		return
+	}
+	from, err1 := strconv.ParseFloat(fields[0], 64)
+	to, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil {
+		fmt.Fprintf(s.out, "usage: :%s from to expr\n", cmd)
+		return
+	}
+	e, ok := s.parse(fields[2])
+	if !ok {
+		return
+	}
+	chart := expr.Plot(e, "x", from, to, s.env)
+	if cmd == "plot" {
+		if err := chart.WriteASCII(s.out); err != nil {
+			fmt.Fprintln(s.out, "error:", err)
+		}
+		return
+	}
+	// Render first, so that a chart that cannot be drawn leaves no file.
+	var buf bytes.Buffer
+	if err := chart.WriteSVG(&buf); err != nil {
+		fmt.Fprintln(s.out, "error:", err)
+		return
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintln(s.out, "error:", err)
+	}
+}
+
+// parse parses and checks src, reporting problems to the user.
+func (s *session) parse(src string) (expr.Expr, bool) {
+	e, err := expr.Parse(src, expr.ImplicitMultiplication())
+	if err != nil {
+		fmt.Fprintln(s.out, "syntax error:", err)
+		return nil, false
+	}
+	if err := e.Check(map[expr.Var]bool{}); err != nil {
+		for _, d := range err.(expr.Diagnostics) {
+			fmt.Fprintln(s.out, "error:", d.Msg)
+		}
+		return nil, false
+	}
+	return e, true
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// A treePrinter prints one node per line, indenting children.
+type treePrinter struct {
+	out    io.Writer
+	indent string
+}
+
+func printTree(out io.Writer, e expr.Expr, indent string) {
+	expr.Accept(e, treePrinter{out, indent})
+	for _, child := range expr.Children(e) {
+		printTree(out, child, indent+"  ")
+	}
+}
+
+func (p treePrinter) VisitVar(v expr.Var) {
+	fmt.Fprintf(p.out, "%svar %s\n", p.indent, v)
+}
+
+func (p treePrinter) VisitLiteral(value float64) {
+	fmt.Fprintf(p.out, "%sliteral %s\n", p.indent, resultFormat.Format(value))
+}
+
+func (p treePrinter) VisitUnary(op rune, x expr.Expr) {
+	fmt.Fprintf(p.out, "%sunary %c\n", p.indent, op)
+}
+
+func (p treePrinter) VisitBinary(op rune, x, y expr.Expr) {
+	fmt.Fprintf(p.out, "%sbinary %c\n", p.indent, op)
+}
+
+func (p treePrinter) VisitCall(fn string, args []expr.Expr) {
+	fmt.Fprintf(p.out, "%scall %s\n", p.indent, fn)
+}
+
+// A history is the line history of the terminal, appended to a file as
+// lines are entered so that it survives restarts.
+type history struct {
+	lines []string // oldest first
+	file  *os.File
+}
+
+func loadHistory(path string) *history {
+	h := new(history)
+	if path == "" {
+		return h
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				h.lines = append(h.lines, line)
+			}
+		}
+		if len(h.lines) > maxHistory {
+			h.lines = h.lines[len(h.lines)-maxHistory:]
+		}
+	}
+	h.file, _ = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	return h
+}
+
+func (h *history) Add(entry string) {
+	h.lines = append(h.lines, entry)
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[1:]
+	}
+	if h.file != nil {
+		fmt.Fprintln(h.file, entry)
+	}
+}
+
+func (h *history) Len() int { return len(h.lines) }
+
+func (h *history) At(idx int) string { return h.lines[len(h.lines)-1-idx] }
+
+func (h *history) Close() error {
+	if h.file == nil {
+		return nil
+	}
+	return h.file.Close()
+}
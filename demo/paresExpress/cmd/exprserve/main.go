@@ -0,0 +1,141 @@
+// Exprserve evaluates expr expressions over HTTP.
+//
+//	POST /eval
+//	{"expr": "5 / 9 * (F - 32)", "env": {"F": 212}}
+//
+// responds with {"value": 100}. Failures are reported with a 4xx status
+// and a structured body:
+//
+//	{"error": {"kind": "check", "message": "...", "diagnostics": [...]}}
+//
+// Expressions are subject to the limits given by the -max-* flags.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/binarycoder777/mini-go-demo/demo/paresExpress/expr"
+)
+
+var (
+	addr     = flag.String("addr", ":8080", "address to listen on")
+	maxDepth = flag.Int("max-depth", expr.DefaultLimits.MaxDepth, "maximum expression nesting depth")
+	maxNodes = flag.Int("max-nodes", expr.DefaultLimits.MaxNodes, "maximum number of expression nodes")
+	maxInput = flag.Int("max-input", expr.DefaultLimits.MaxInputLen, "maximum expression length in bytes")
+	timeout  = flag.Duration("timeout", time.Second, "maximum evaluation time")
+)
+
+// maxBody bounds the request body, which holds the environment besides
+// the expression.
+const maxBody = 1 << 20
+
+type evalRequest struct {
+	Expr string   `json:"expr"`
+	Env  expr.Env `json:"env"`
+}
+
+type evalResponse struct {
+	Value *float64   `json:"value,omitempty"`
+	Error *evalError `json:"error,omitempty"`
+}
+
+type evalError struct {
+	Kind        string       `json:"kind"` // request, syntax, limit, check or eval
+	Message     string       `json:"message"`
+	Diagnostics []diagnostic `json:"diagnostics,omitempty"`
+}
+
+type diagnostic struct {
+	Kind    string `json:"kind"`
+	Path    []int  `json:"path"`
+	Message string `json:"message"`
+}
+
+func main() {
+	flag.Parse()
+	limits := expr.Limits{MaxDepth: *maxDepth, MaxNodes: *maxNodes, MaxInputLen: *maxInput}
+
+	http.Handle("/eval", evalHandler(limits, *timeout))
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func evalHandler(limits expr.Limits, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			reply(w, http.StatusMethodNotAllowed, &evalError{Kind: "request", Message: "use POST"})
+			return
+		}
+
+		var req evalRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&req); err != nil {
+			reply(w, http.StatusBadRequest, &evalError{Kind: "request", Message: err.Error()})
+			return
+		}
+
+		e, err := expr.Parse(req.Expr, expr.ParseLimits(limits))
+		if err != nil {
+			reply(w, http.StatusUnprocessableEntity, classify("syntax", err))
+			return
+		}
+		if err := e.Check(map[expr.Var]bool{}); err != nil {
+			reply(w, http.StatusUnprocessableEntity, classify("check", err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		v, err := expr.EvalContext(ctx, e, req.Env, expr.EvalLimits(limits))
+		if err != nil {
+			reply(w, http.StatusUnprocessableEntity, classify("eval", err))
+			return
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// JSON has no representation for these values.
+			reply(w, http.StatusUnprocessableEntity, &evalError{Kind: "eval", Message: "result is not a finite number"})
+			return
+		}
+		writeJSON(w, http.StatusOK, evalResponse{Value: &v})
+	})
+}
+
+// classify turns err into a structured error of the given kind, or of
+// kind "limit" if a limit was exceeded.
+func classify(kind string, err error) *evalError {
+	var le *expr.LimitError
+	if errors.As(err, &le) {
+		return &evalError{Kind: "limit", Message: err.Error()}
+	}
+	ee := &evalError{Kind: kind, Message: err.Error()}
+	var ds expr.Diagnostics
+	if errors.As(err, &ds) {
+		for _, d := range ds {
+			ee.Diagnostics = append(ee.Diagnostics, diagnostic{
+				Kind:    d.Kind.String(),
+				Path:    append([]int{}, d.Path...),
+				Message: d.Msg,
+			})
+		}
+	}
+	return ee
+}
+
+func reply(w http.ResponseWriter, status int, e *evalError) {
+	writeJSON(w, status, evalResponse{Error: e})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
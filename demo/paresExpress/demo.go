@@ -1,260 +1,221 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"math"
-	"strconv"
+	"os"
 	"strings"
-)
-
-// An Env that mapping var -> value
-type Env map[Var]float64
-
-// An Expr interface is an arithmetic expression.
-type Expr interface {
-	Eval(env Env) float64
-	String() string
-	Check(vars map[Var]bool) error
-}
-
-// A Var identifies a variable, e.g., x.
-type Var string
+	"time"
 
-func (v Var) Eval(env Env) float64 {
-	return env[v]
-}
-
-func (v Var) Check(vars map[Var]bool) error {
-	vars[v] = true
-	return nil
-}
+	"github.com/binarycoder777/mini-go-demo/demo/paresExpress/expr"
+)
 
-func (v Var) String() string {
-	return "变量:" + string(v)
-}
+func main() {
+	env := expr.Env{"x": 3, "y": 4}
+	xy := expr.Unary('-', expr.Var("y"))
 
-// A literal is a numeric constant, e.g., 3.141.
-type literal float64
+	add := expr.Binary('+', expr.Var("x"), expr.Var("y"))
 
-func (l literal) Eval(env Env) float64 {
-	return float64(l)
-}
+	// 乘
+	mul := expr.Binary('*', expr.Var("x"), expr.Var("y"))
 
-func (literal) Check(vars map[Var]bool) error {
-	return nil
-}
+	// pow
+	pow := expr.Call("pow", expr.Var("x"), expr.Var("y"))
 
-func (l literal) String() string {
-	return "常量:" + strconv.FormatFloat(float64(l), 'f', -1, 64)
+	fmt.Println("xy:", add.Eval(env))
+	fmt.Println(xy.Eval(env))
+	fmt.Println(mul.Eval(env))
+	fmt.Println(pow.Eval(env))
 
-}
+	var val expr.Expr = expr.Var("x")
+	fmt.Println(val)
+	val = expr.Lit(234.323233)
+	fmt.Println(val)
+	fmt.Println(xy)
+	fmt.Println(add)
+	fmt.Println(mul)
+	fmt.Println(pow)
 
-// A unary represents a unary operator expression, e.g., -x.
-type unary struct {
-	op rune // one of '+' | '-'
-	x  Expr
-}
+	wawa := expr.Env{"q": 1, "w": 2, "e": 3, "x": 3, "y": 4}
+	fmt.Println("变量列表：", wawa)
+	taowa := expr.Call("pow",
+		add,
+		expr.Call("sqrt", expr.Binary('+', expr.Var("e"), expr.Var("q"))),
+	)
+	fmt.Println(taowa)            // pow(x + y, sqrt(e + q))
+	fmt.Println(taowa.Eval(wawa)) // 49
 
-func (u unary) Eval(env Env) float64 {
-	switch u.op {
-	case '+':
-		return +u.x.Eval(env)
-	case '-':
-		return -u.x.Eval(env)
+	// 调试模式下输出详细结构
+	expr.Debug = true
+	fmt.Println(taowa) // 函数:pow((变量:x | 操作符号:'+' | 变量:y), 函数:sqrt((变量:e | 操作符号:'+' | 变量:q)))
+	expr.Debug = false
+
+	// 编译为闭包，按位置传参
+	fn := expr.CompileFunc(taowa, []expr.Var{"x", "y", "e", "q"})
+	fmt.Println(fn(3, 4, 3, 1)) // 49
+
+	// 带缓存的求值，相同的子表达式只计算一次
+	cache := expr.NewEvalCache(128)
+	fmt.Println(cache.Eval(taowa, wawa))                                     // 49
+	fmt.Println(cache.Eval(taowa, expr.Env{"x": 3, "y": 4, "e": 3, "q": 1})) // 49, 命中缓存
+	fmt.Println("缓存条目:", cache.Len())
+
+	// 部分求值，已知变量替换为常量并折叠
+	residual := expr.PartialEval(taowa, expr.Env{"e": 3, "q": 1})
+	fmt.Println(residual)                                // pow(x + y, 2)
+	fmt.Println(residual.Eval(expr.Env{"x": 3, "y": 4})) // 49
+
+	// 规范化，交换律等价的表达式输出相同
+	fmt.Println(expr.Normalize(expr.Binary('+', expr.Var("y"), expr.Unary('-', expr.Var("x"))))) // y - x
+	a := expr.Binary('*', expr.Binary('+', expr.Var("y"), expr.Var("x")), expr.Lit(2))
+	b := expr.Binary('*', expr.Lit(2), expr.Binary('+', expr.Var("x"), expr.Var("y")))
+	fmt.Println(expr.Normalize(a).String() == expr.Normalize(b).String()) // true
+
+	// 遍历语法树，统计节点
+	var nodes int
+	expr.Walk(taowa, func(expr.Expr) bool { nodes++; return true })
+	fmt.Println("节点数:", nodes, "变量:", expr.Vars(taowa)) // 节点数: 8 变量: [x y e q]
+
+	// 改写语法树，把 F 替换为摄氏度换算式
+	celsius := expr.Binary('*', expr.Binary('/', expr.Lit(5), expr.Lit(9)), expr.Binary('-', expr.Var("F"), expr.Lit(32)))
+	roundTrip := expr.Rewrite(celsius, func(n expr.Expr) (expr.Expr, bool) {
+		if n == expr.Var("F") {
+			return expr.Binary('+', expr.Binary('/', expr.Binary('*', expr.Var("C"), expr.Lit(9)), expr.Lit(5)), expr.Lit(32)), true
+		}
+		return n, false
+	})
+	fmt.Println(roundTrip.Eval(expr.Env{"C": 100})) // 100
+
+	// JSON 序列化与反序列化
+	data, err := json.Marshal(celsius)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	panic(fmt.Sprintf("unsupported unary operator: %q", u.op))
-}
-
-func (u unary) Check(vars map[Var]bool) error {
-	if !strings.ContainsRune("+-", u.op) {
-		return fmt.Errorf("unexpected unary op %q", u.op)
+	fmt.Println(string(data))
+	decoded, err := expr.UnmarshalExpr(data)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	return u.x.Check(vars)
-}
-
-func (u unary) String() string {
-	return "(操作符号:" + strconv.QuoteRuneToASCII(u.op) + " | " +
-		u.x.String() + ")"
-
-}
-
-// A binary represents a binary operator expression, e.g., x+y.
-type binary struct {
-	op   rune // one of '+', '-', '*', '/'
-	x, y Expr
-}
-
-func (b binary) Eval(env Env) float64 {
-	switch b.op {
-	case '+':
-		return b.x.Eval(env) + b.y.Eval(env)
-	case '-':
-		return b.x.Eval(env) - b.y.Eval(env)
-	case '*':
-		return b.x.Eval(env) * b.y.Eval(env)
-	case '/':
-		return b.x.Eval(env) / b.y.Eval(env)
+	fmt.Println(decoded.Eval(expr.Env{"F": 212})) // 100
+
+	// S 表达式
+	fmt.Println(expr.ToSExpr(taowa)) // (pow (+ x y) (sqrt (+ e q)))
+	parsed, err := expr.ParseSExpr("(pow (+ x y) (sqrt (+ e q)))")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	panic(fmt.Sprintf("unsupported unary operator: %q", b.op))
-}
+	fmt.Println(parsed.Eval(wawa)) // 49
 
-func (b binary) Check(vars map[Var]bool) error {
-	if !strings.ContainsRune("+-*/", b.op) {
-		return fmt.Errorf("unexpected binary op %q", b.op)
+	// 逆波兰表达式
+	rpn, err := expr.ParseRPN("x y + e q + sqrt pow")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	if err := b.x.Check(vars); err != nil {
-		return err
-	}
-	return b.y.Check(vars)
-}
+	fmt.Println(expr.ToSExpr(rpn) == expr.ToSExpr(taowa)) // true
 
-func (b binary) String() string {
-	return "(" + b.x.String() + " | 操作符号:" +
-		strconv.QuoteRuneToASCII(b.op) + " | " + b.y.String() + ")"
+	// LaTeX 输出
+	fmt.Println(expr.ToLaTeX(taowa))   // \left(x + y\right)^{\sqrt{e + q}}
+	fmt.Println(expr.ToLaTeX(celsius)) // \frac{5}{9} \cdot \left(F - 32\right)
 
-}
-
-// A call represents a function call expression, e.g., sin(x).
-type call struct {
-	fn   string // one of "pow", "sin", "sqrt"
-	args []Expr
-}
-
-func (c call) Eval(env Env) float64 {
-	switch c.fn {
-	case "pow":
-		return math.Pow(c.args[0].Eval(env), c.args[1].Eval(env))
-	case "sin":
-		return math.Sin(c.args[0].Eval(env))
-	case "sqrt":
-		return math.Sqrt(c.args[0].Eval(env))
+	// 解析中缀表达式
+	infix, err := expr.Parse("5 / 9 * (F - 32)")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	panic(fmt.Sprintf("unsupported function call: %s", c.fn))
-}
+	fmt.Println(infix.Eval(expr.Env{"F": 212})) // 100
 
-var numParams = map[string]int{"pow": 2, "sin": 1, "sqrt": 1}
+	// 隐式乘法
+	textbook, err := expr.Parse("2x(x+1)(x-1) + 3sin(x)", expr.ImplicitMultiplication())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(textbook) // 2 * x * (x + 1) * (x - 1) + 3 * sin(x)
 
-func (c call) Check(vars map[Var]bool) error {
-	arity, ok := numParams[c.fn]
-	if !ok {
-		return fmt.Errorf("unknown function %q", c.fn)
+	// 十六进制、二进制、科学计数法和数字分隔符
+	numbers, err := expr.Parse("0xFF + 0b1010 * 1_000 + 1.5e-3")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	if len(c.args) != arity {
-		return fmt.Errorf("call to %s has %d args, want %d",
-			c.fn, len(c.args), arity)
+	fmt.Println(numbers, "=", expr.DefaultNumberFormat.Format(numbers.Eval(nil))) // 255 + 10 * 1000 + 0.0015 = 10255.0015
+
+	// 结果格式化：精度、科学计数法、千位分隔符、小数逗号
+	european := expr.NumberFormat{Notation: 'f', Precision: 2, Thousands: ".", DecimalComma: true}
+	fmt.Println(european.Format(numbers.Eval(nil)))                                       // 10.255,00
+	fmt.Println(expr.NumberFormat{Notation: 'e', Precision: 3}.Format(numbers.Eval(nil))) // 1.026e+04
+
+	// 检查表达式，一次报告所有错误
+	bad, err := expr.Parse("foo(x) + pow(y) * sqrt(z, 1)")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	for _, arg := range c.args {
-		if err := arg.Check(vars); err != nil {
-			return err
+	if err := bad.Check(map[expr.Var]bool{}); err != nil {
+		for _, d := range err.(expr.Diagnostics) {
+			fmt.Printf("%s %v: %s\n", d.Kind, d.Path, d.Msg)
 		}
 	}
-	return nil
-}
 
-func (c call) String() string {
-	var args string
-	for i, v := range c.args {
-		args += v.String()
-		if i < len(c.args)-1 {
-			args += ", "
-		}
+	// 限制不可信输入的深度和大小
+	_, err = expr.Parse(strings.Repeat("(", 1000) + "x" + strings.Repeat(")", 1000))
+	fmt.Println(err) // expression exceeds maximum depth 200
+	_, err = expr.Evaluate(taowa, wawa, expr.EvalLimits(expr.Limits{MaxNodes: 5}))
+	fmt.Println(err) // expression exceeds maximum node count 5
+
+	// 带超时的求值
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := expr.EvalContext(ctx, taowa, wawa)
+	fmt.Println(v, err) // 49 <nil>
+
+	// 模板：解析一次，多次求值
+	toCelsius := expr.MustTemplate("5 / 9 * (F - 32)", "F")
+	for _, f := range []float64{-40, 32, 212} {
+		c, _ := toCelsius.Eval(f)
+		fmt.Printf("%gF = %gC\n", f, c)
 	}
-	//fmt.Println(args)
-	return "函数:" + c.fn + "(" + args + ")"
-}
-
-// Test Eval
-//func TestEval(t *testing.T) {
-//	tests := []struct {
-//		expr string
-//		env  Env
-//		want string
-//	}{
-//		{"sqrt(A / pi)", Env{"A": 87616, "pi": math.Pi}, "167"},
-//		{"pow(x, 3) + pow(y, 3)", Env{"x": 12, "y": 1}, "1729"},
-//		{"pow(x, 3) + pow(y, 3)", Env{"x": 9, "y": 10}, "1729"},
-//		{"5 / 9 * (F - 32)", Env{"F": -40}, "-40"},
-//		{"5 / 9 * (F - 32)", Env{"F": 32}, "0"},
-//		{"5 / 9 * (F - 32)", Env{"F": 212}, "100"},
-//	}
-//	var prevExpr string
-//	for _, test := range tests {
-//		if test.expr != prevExpr {
-//			fmt.Printf("\n%s\n", test.expr)
-//			prevExpr = test.expr
-//		}
-//		expr, err := Parse(test.expr)
-//		if err != nil {
-//			t.Error(err)
-//			continue
-//		}
-//		got := fmt.Sprintf("%.6g", expr.Eval(test.env))
-//		fmt.Printf("\t%v => %s\n", test.env, got)
-//		if got != test.want {
-//			t.Errorf("%s.Eval() in %v = %q, want %q\n",
-//				test.expr, test.env, got, test.want)
-//		}
-//	}
-//}
-
-func main() {
-	env := Env{"x": 3, "y": 4}
-	xy := unary{
-		op: '-',
-		x:  Var("y"),
+	_, err = toCelsius.EvalEnv(expr.Env{})
+	fmt.Println(err) // template 5 / 9 * (F - 32): missing parameters F
+
+	// 随机数函数，指定种子后结果可复现
+	roll, err := expr.Parse("randint(1, 6) + randint(1, 6) + normal(0, 0.1)")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-
-	add := binary{
-		op: '+',
-		x:  Var("x"),
-		y:  Var("y"),
+	first, _ := expr.Evaluate(roll, nil, expr.EvalSeed(42))
+	second, _ := expr.Evaluate(roll, nil, expr.EvalSeed(42))
+	fmt.Println(first == second) // true
+
+	// 日期和时长：时间为 Unix 秒数，时长为秒数
+	deadline, err := expr.Parse("(date(2024, 3, 1) - date(2024, 2, 1)) / 24h")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-
-	// 乘
-	mul := binary{
-		op: '*',
-		x:  Var("x"),
-		y:  Var("y"),
+	fmt.Println(deadline.Eval(nil)) // 29
+	meeting, _ := expr.Parse("1h30m + 45m")
+	fmt.Println(time.Duration(meeting.Eval(nil) * float64(time.Second))) // 2h15m0s
+
+	// 可变参数的统计函数
+	summary, err := expr.Parse("mean(2, 4, 4, 4, 5, 5, 7, 9) + stddev(2, 4, 4, 4, 5, 5, 7, 9) + percentile(50, 1, 3, 2)")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
+	fmt.Println(summary.Eval(nil)) // 5 + 2 + 2 = 9
 
-	// pow
-	pow := call{
-		fn:   "pow",
-		args: []Expr{Var("x"), Var("y")},
-	}
-
-	fmt.Println("xy:", add.Eval(env))
-	fmt.Println(xy.Eval(env))
-	fmt.Println(mul.Eval(env))
-	fmt.Println(pow.Eval(env))
-
-	var val Expr = Var("x")
-	fmt.Println(val)
-	val = literal(234.323233)
-	fmt.Println(val)
-	fmt.Println(xy)
-	fmt.Println(add)
-	fmt.Println(mul)
-	fmt.Println(pow)
-
-	wawa := Env{"q": 1, "w": 2, "e": 3, "x": 3, "y": 4}
-	fmt.Println("变量列表：", wawa)
-	taowa := call{
-		fn: "pow",
-		args: []Expr{
-			add,
-			call{
-				fn: "sqrt",
-				args: []Expr{
-					binary{
-						op: '+',
-						x:  Var("e"),
-						y:  Var("q"),
-					},
-				},
-			},
-		},
+	// 绘制函数图像
+	chart := expr.Plot(textbook, "x", -1.5, 1.5, nil)
+	chart.Width, chart.Height = 60, 12
+	if err := chart.WriteASCII(os.Stdout); err != nil {
+		fmt.Println(err)
 	}
-	fmt.Println(taowa)            // 函数:pow((变量:x | 操作符号:'+' | 变量:y), 函数:sqrt((变量:e | 操作符号:'+' | 变量:q)))
-	fmt.Println(taowa.Eval(wawa)) // 49
 }
@@ -0,0 +1,278 @@
+package expr
+
+import (
+	"container/list"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// An EvalCache memoizes evaluation results keyed by the structure of an
+// expression and the values of the variables it reads. Every composite
+// sub-expression is cached separately, so identical sub-expressions shared
+// by different expressions, or evaluated in environments that only differ
+// in unrelated variables, are computed once.
+//
+// Sub-expressions calling rand, randint or normal are never cached.
+// The least recently used entries are evicted once the cache is full.
+// An EvalCache is safe for concurrent use.
+type EvalCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List // of *cacheEntry, most recently used at the front
+	items    map[cacheKey]*list.Element
+}
+
+type cacheKey struct {
+	expr uint64 // hash of the expression structure
+	env  uint64 // fingerprint of the variables the expression reads
+}
+
+// A cacheEntry keeps the expression and the values of the variables it
+// read alongside its result, so that a hash collision is detected rather
+// than returning the value of another expression.
+type cacheEntry struct {
+	key   cacheKey
+	expr  Expr
+	vals  []float64 // of the variables the expression reads, in order
+	value float64
+}
+
+// A cacheNode annotates an expression, and each of its sub-expressions,
+// with what the cache needs to know about it. Annotations are computed
+// bottom-up in a single pass, so Eval is linear in the size of the tree.
+type cacheNode struct {
+	e    Expr
+	hash uint64 // structure hash
+	pure bool   // no calls to impure functions
+	vars []Var  // variables read, sorted
+	args []*cacheNode
+}
+
+// annotate returns the annotated tree of e.
+func annotate(e Expr) *cacheNode {
+	n := &cacheNode{e: e, pure: true}
+	h := fnv.New64a()
+	switch e := e.(type) {
+	case Var:
+		h.Write([]byte("v:" + e))
+		n.vars = []Var{e}
+	case literal:
+		h.Write([]byte("l:"))
+		writeUint64(h, math.Float64bits(float64(e)))
+	case unary:
+		fmt.Fprintf(h, "u%c", e.op)
+		n.args = []*cacheNode{annotate(e.x)}
+	case binary:
+		fmt.Fprintf(h, "b%c", e.op)
+		n.args = []*cacheNode{annotate(e.x), annotate(e.y)}
+	case call:
+		h.Write([]byte("c:" + e.fn))
+		n.pure = !impure[e.fn]
+		for _, arg := range e.args {
+			n.args = append(n.args, annotate(arg))
+		}
+	default:
+		// Other Expr implementations are treated as opaque.
+		h.Write([]byte(exprKey(e)))
+		vars := make(map[Var]bool)
+		e.Check(vars) // only used to collect variables
+		for v := range vars {
+			n.vars = append(n.vars, v)
+		}
+		sort.Slice(n.vars, func(i, j int) bool { return n.vars[i] < n.vars[j] })
+		n.pure = pure(e)
+	}
+	for _, arg := range n.args {
+		writeUint64(h, arg.hash)
+		n.pure = n.pure && arg.pure
+		n.vars = mergeVars(n.vars, arg.vars)
+	}
+	n.hash = h.Sum64()
+	return n
+}
+
+// mergeVars returns the sorted union of the sorted lists a and b.
+func mergeVars(a, b []Var) []Var {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	merged := make([]Var, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			merged, a = append(merged, a[0]), a[1:]
+		case b[0] < a[0]:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			merged, a, b = append(merged, a[0]), a[1:], b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+func writeUint64(h hash.Hash64, x uint64) {
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(x >> (8 * i))
+	}
+	h.Write(buf[:])
+}
+
+// NewEvalCache returns a cache holding at most capacity results.
+func NewEvalCache(capacity int) *EvalCache {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("invalid eval cache capacity: %d", capacity))
+	}
+	return &EvalCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[cacheKey]*list.Element),
+	}
+}
+
+// Len reports the number of cached results.
+func (c *EvalCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Eval evaluates e in env, reusing cached results where possible.
+func (c *EvalCache) Eval(e Expr, env Env) float64 {
+	return c.eval(annotate(e), env)
+}
+
+func (c *EvalCache) eval(n *cacheNode, env Env) float64 {
+	switch e := n.e.(type) {
+	case Var, literal:
+		return e.Eval(env) // cheaper than a lookup
+	case unary:
+		return c.memo(n, env, func() float64 {
+			return unary{e.op, literal(c.eval(n.args[0], env))}.Eval(nil)
+		})
+	case binary:
+		return c.memo(n, env, func() float64 {
+			return binary{e.op, literal(c.eval(n.args[0], env)), literal(c.eval(n.args[1], env))}.Eval(nil)
+		})
+	case call:
+		return c.memo(n, env, func() float64 {
+			args := make([]Expr, len(n.args))
+			for i, arg := range n.args {
+				args[i] = literal(c.eval(arg, env))
+			}
+			return call{e.fn, args}.Eval(nil)
+		})
+	}
+	return c.memo(n, env, func() float64 { return n.e.Eval(env) })
+}
+
+func (c *EvalCache) memo(n *cacheNode, env Env, eval func() float64) float64 {
+	if !n.pure {
+		return eval() // a fresh value is wanted every time
+	}
+	vals := make([]float64, len(n.vars))
+	h := fnv.New64a()
+	for i, v := range n.vars {
+		vals[i] = env[v]
+		h.Write([]byte(v))
+		writeUint64(h, math.Float64bits(vals[i]))
+	}
+	key := cacheKey{n.hash, h.Sum64()}
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok && el.Value.(*cacheEntry).matches(n.e, vals) {
+		c.ll.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*cacheEntry).value
+	}
+	c.mu.Unlock()
+
+	// Evaluate without holding the lock: eval recurses into c.
+	v := eval()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cacheEntry{key, n.e, vals, v}
+	if el, ok := c.items[key]; ok {
+		// Either another goroutine stored the same result meanwhile, or
+		// the key collides with another expression, which is replaced.
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return v
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+	return v
+}
+
+// matches reports whether the entry holds the result of e for the
+// variable values vals.
+func (entry *cacheEntry) matches(e Expr, vals []float64) bool {
+	if len(entry.vals) != len(vals) {
+		return false
+	}
+	for i := range vals {
+		if math.Float64bits(entry.vals[i]) != math.Float64bits(vals[i]) {
+			return false
+		}
+	}
+	return Equal(entry.expr, e)
+}
+
+// exprKey returns an unambiguous textual key for the structure of e.
+// Unlike String, it distinguishes every node type, e.g. the literal -1
+// from the unary minus applied to 1.
+func exprKey(e Expr) string {
+	var b strings.Builder
+	writeKey(&b, e)
+	return b.String()
+}
+
+func writeKey(b *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case Var:
+		b.WriteString("v:")
+		b.WriteString(string(e))
+	case literal:
+		b.WriteString("l:")
+		b.WriteString(strconv.FormatFloat(float64(e), 'g', -1, 64))
+	case unary:
+		b.WriteString("(u")
+		b.WriteRune(e.op)
+		b.WriteByte(' ')
+		writeKey(b, e.x)
+		b.WriteByte(')')
+	case binary:
+		b.WriteString("(b")
+		b.WriteRune(e.op)
+		b.WriteByte(' ')
+		writeKey(b, e.x)
+		b.WriteByte(' ')
+		writeKey(b, e.y)
+		b.WriteByte(')')
+	case call:
+		b.WriteString("(c:")
+		b.WriteString(e.fn)
+		for _, arg := range e.args {
+			b.WriteByte(' ')
+			writeKey(b, arg)
+		}
+		b.WriteByte(')')
+	default:
+		fmt.Fprintf(b, "%T:%s", e, e)
+	}
+}
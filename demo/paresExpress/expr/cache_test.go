@@ -0,0 +1,28 @@
+package expr
+
+import "testing"
+
+// TestEvalCacheCollision checks that an entry stored under the key of
+// another expression is not mistaken for its result.
+func TestEvalCacheCollision(t *testing.T) {
+	c := NewEvalCache(8)
+	env := Env{"x": 2}
+	if got := c.Eval(binary{'+', Var("x"), literal(1)}, env); got != 3 {
+		t.Fatalf("x + 1 = %g, want 3", got)
+	}
+
+	// Move the entry of x + 1 to the key of x * 10, as a hash collision
+	// would.
+	mul := binary{'*', Var("x"), literal(10)}
+	n := annotate(mul)
+	for key, el := range c.items {
+		delete(c.items, key)
+		key.expr = n.hash
+		el.Value.(*cacheEntry).key = key
+		c.items[key] = el
+	}
+
+	if got := c.Eval(mul, env); got != 20 {
+		t.Errorf("x * 10 = %g after a collision, want 20", got)
+	}
+}
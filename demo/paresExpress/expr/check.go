@@ -0,0 +1,124 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A DiagKind classifies a problem reported by Check.
+type DiagKind int
+
+const (
+	BadOperator     DiagKind = iota + 1 // operator not supported by the node
+	UnknownFunction                     // call to a function that does not exist
+	BadArity                            // call with the wrong number of arguments
+	Other                               // error from an Expr implementation outside this package
+)
+
+func (k DiagKind) String() string {
+	switch k {
+	case BadOperator:
+		return "bad operator"
+	case UnknownFunction:
+		return "unknown function"
+	case BadArity:
+		return "bad arity"
+	}
+	return "error"
+}
+
+// A Diagnostic describes one problem found in an expression.
+type Diagnostic struct {
+	Kind DiagKind
+	// Path locates the offending node: the indices, as returned by
+	// Children, of the sub-expressions leading to it from the checked root.
+	// It is empty for the root itself.
+	Path []int
+	Node Expr
+	Msg  string
+}
+
+func (d Diagnostic) Error() string {
+	if len(d.Path) == 0 {
+		return d.Msg
+	}
+	return fmt.Sprintf("at %v: %s", d.Path, d.Msg)
+}
+
+// Diagnostics is the error returned by Check. It lists every problem
+// found, in pre-order.
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) Error() string {
+	msgs := make([]string, len(ds))
+	for i, d := range ds {
+		msgs[i] = d.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// check validates the whole tree rooted at e, recording the variables it
+// reads in vars. It returns nil or a non-empty Diagnostics.
+func check(e Expr, vars map[Var]bool) error {
+	var ds Diagnostics
+	diagnose(e, vars, nil, &ds)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds
+}
+
+func diagnose(e Expr, vars map[Var]bool, path []int, ds *Diagnostics) {
+	report := func(kind DiagKind, format string, args ...interface{}) {
+		*ds = append(*ds, Diagnostic{
+			Kind: kind,
+			Path: append([]int(nil), path...),
+			Node: e,
+			Msg:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	switch e := e.(type) {
+	case Var:
+		vars[e] = true
+	case literal:
+		// always valid
+	case unary:
+		if !strings.ContainsRune("+-", e.op) {
+			report(BadOperator, "unexpected unary op %q", e.op)
+		}
+		diagnose(e.x, vars, append(path, 0), ds)
+	case binary:
+		if !strings.ContainsRune("+-*/", e.op) {
+			report(BadOperator, "unexpected binary op %q", e.op)
+		}
+		diagnose(e.x, vars, append(path, 0), ds)
+		diagnose(e.y, vars, append(path, 1), ds)
+	case call:
+		if min, ok := minParams[e.fn]; ok {
+			if len(e.args) < min {
+				report(BadArity, "call to %s has %d args, want at least %d",
+					e.fn, len(e.args), min)
+			}
+		} else if arity, ok := numParams[e.fn]; !ok {
+			report(UnknownFunction, "unknown function %q", e.fn)
+		} else if len(e.args) != arity {
+			report(BadArity, "call to %s has %d args, want %d",
+				e.fn, len(e.args), arity)
+		}
+		for i, arg := range e.args {
+			diagnose(arg, vars, append(path, i), ds)
+		}
+	default:
+		switch err := e.Check(vars).(type) {
+		case nil:
+		case Diagnostics:
+			for _, d := range err {
+				d.Path = append(append([]int(nil), path...), d.Path...)
+				*ds = append(*ds, d)
+			}
+		default:
+			report(Other, "%v", err)
+		}
+	}
+}
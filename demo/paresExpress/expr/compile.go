@@ -0,0 +1,124 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// A compiled is a closure produced by CompileFunc for one node of the tree.
+// It reads variable values positionally from args instead of looking
+// them up in an Env.
+type compiled func(args []float64) float64
+
+// CompileFunc translates e into a tree of closures that perform the float
+// operations directly, taking variable values positionally in the order
+// given by vars. Variables of e that do not appear in vars evaluate to 0,
+// just as a missing entry in an Env does.
+//
+// The returned function panics if it is called with fewer arguments than
+// len(vars). e should have passed Check beforehand.
+func CompileFunc(e Expr, vars []Var) func(...float64) float64 {
+	index := make(map[Var]int, len(vars))
+	for i, v := range vars {
+		if _, ok := index[v]; !ok {
+			index[v] = i
+		}
+	}
+	fn := compile(e, index)
+	return func(args ...float64) float64 {
+		if len(args) < len(vars) {
+			panic(fmt.Sprintf("compiled expression called with %d args, want %d",
+				len(args), len(vars)))
+		}
+		return fn(args)
+	}
+}
+
+func compile(e Expr, index map[Var]int) compiled {
+	switch e := e.(type) {
+	case Var:
+		i, ok := index[e]
+		if !ok {
+			return func([]float64) float64 { return 0 }
+		}
+		return func(args []float64) float64 { return args[i] }
+	case literal:
+		v := float64(e)
+		return func([]float64) float64 { return v }
+	case unary:
+		x := compile(e.x, index)
+		switch e.op {
+		case '+':
+			return x
+		case '-':
+			return func(args []float64) float64 { return -x(args) }
+		}
+		panic(fmt.Sprintf("unsupported unary operator: %q", e.op))
+	case binary:
+		x, y := compile(e.x, index), compile(e.y, index)
+		switch e.op {
+		case '+':
+			return func(args []float64) float64 { return x(args) + y(args) }
+		case '-':
+			return func(args []float64) float64 { return x(args) - y(args) }
+		case '*':
+			return func(args []float64) float64 { return x(args) * y(args) }
+		case '/':
+			return func(args []float64) float64 { return x(args) / y(args) }
+		}
+		panic(fmt.Sprintf("unsupported binary operator: %q", e.op))
+	case call:
+		args := make([]compiled, len(e.args))
+		for i, arg := range e.args {
+			args[i] = compile(arg, index)
+		}
+		switch e.fn {
+		case "pow":
+			x, y := args[0], args[1]
+			return func(a []float64) float64 { return math.Pow(x(a), y(a)) }
+		case "sin":
+			x := args[0]
+			return func(a []float64) float64 { return math.Sin(x(a)) }
+		case "sqrt":
+			x := args[0]
+			return func(a []float64) float64 { return math.Sqrt(x(a)) }
+		case "rand", "randint", "normal":
+			fn := e.fn
+			return func(a []float64) float64 {
+				vals := make([]float64, len(args))
+				for i, arg := range args {
+					vals[i] = arg(a)
+				}
+				return randomCall(fn, vals, globalRand{})
+			}
+		case "now", "date":
+			fn := e.fn
+			return func(a []float64) float64 {
+				vals := make([]float64, len(args))
+				for i, arg := range args {
+					vals[i] = arg(a)
+				}
+				return timeCall(fn, vals)
+			}
+		case "mean", "median", "variance", "stddev", "percentile":
+			fn := e.fn
+			return func(a []float64) float64 {
+				vals := make([]float64, len(args))
+				for i, arg := range args {
+					vals[i] = arg(a)
+				}
+				return statsCall(fn, vals)
+			}
+		}
+		panic(fmt.Sprintf("unsupported function call: %s", e.fn))
+	}
+
+	// Unknown Expr implementations fall back to the interpreter.
+	return func(args []float64) float64 {
+		env := make(Env, len(index))
+		for v, i := range index {
+			env[v] = args[i]
+		}
+		return e.Eval(env)
+	}
+}
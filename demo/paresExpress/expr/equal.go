@@ -0,0 +1,31 @@
+package expr
+
+// Equal reports whether a and b are structurally identical expressions.
+func Equal(a, b Expr) bool {
+	switch a := a.(type) {
+	case Var:
+		b, ok := b.(Var)
+		return ok && a == b
+	case literal:
+		b, ok := b.(literal)
+		return ok && (a == b || a != a && b != b) // NaN equals NaN
+	case unary:
+		b, ok := b.(unary)
+		return ok && a.op == b.op && Equal(a.x, b.x)
+	case binary:
+		b, ok := b.(binary)
+		return ok && a.op == b.op && Equal(a.x, b.x) && Equal(a.y, b.y)
+	case call:
+		b, ok := b.(call)
+		if !ok || a.fn != b.fn || len(a.args) != len(b.args) {
+			return false
+		}
+		for i := range a.args {
+			if !Equal(a.args[i], b.args[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
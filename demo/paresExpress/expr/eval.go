@@ -0,0 +1,98 @@
+package expr
+
+import "context"
+
+// An EvalOption configures Evaluate and EvalContext.
+type EvalOption func(*evaluator)
+
+// EvalLimits replaces DefaultLimits for one evaluation.
+func EvalLimits(l Limits) EvalOption {
+	return func(ev *evaluator) { ev.limits = l }
+}
+
+// cancelCheckInterval is the number of nodes evaluated between checks
+// of the context.
+const cancelCheckInterval = 64
+
+// An evaluator holds the state of a single evaluation.
+type evaluator struct {
+	limits Limits
+	ctx    context.Context
+	env    Env
+	steps  int
+	rand   randSource // set by WithSeed
+}
+
+// Evaluate evaluates e in env like e.Eval, but first verifies that e is
+// within the configured Limits, reporting a *LimitError otherwise.
+func Evaluate(e Expr, env Env, opts ...EvalOption) (float64, error) {
+	return EvalContext(context.Background(), e, env, opts...)
+}
+
+// EvalContext is like Evaluate but periodically checks ctx while walking
+// the tree, abandoning the evaluation with ctx.Err() once the context is
+// cancelled or its deadline passes.
+func EvalContext(ctx context.Context, e Expr, env Env, opts ...EvalOption) (float64, error) {
+	ev := &evaluator{limits: DefaultLimits, ctx: ctx, env: env}
+	for _, opt := range opts {
+		opt(ev)
+	}
+	if err := ev.limits.Check(e); err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return ev.eval(e)
+}
+
+func (ev *evaluator) eval(e Expr) (float64, error) {
+	ev.steps++
+	if ev.steps%cancelCheckInterval == 0 {
+		if err := ev.ctx.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Operands are evaluated here and handed to the node's own Eval as
+	// literals, so that operator semantics stay defined in one place.
+	switch e := e.(type) {
+	case unary:
+		x, err := ev.eval(e.x)
+		if err != nil {
+			return 0, err
+		}
+		return unary{e.op, literal(x)}.Eval(nil), nil
+	case binary:
+		x, err := ev.eval(e.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := ev.eval(e.y)
+		if err != nil {
+			return 0, err
+		}
+		return binary{e.op, literal(x), literal(y)}.Eval(nil), nil
+	case call:
+		if err := e.arity(); err != nil {
+			return 0, err
+		}
+		args := make([]Expr, len(e.args))
+		vals := make([]float64, len(e.args))
+		for i, arg := range e.args {
+			v, err := ev.eval(arg)
+			if err != nil {
+				return 0, err
+			}
+			args[i], vals[i] = literal(v), v
+		}
+		switch e.fn {
+		case "rand", "randint", "normal":
+			if ev.rand != nil {
+				return randomCall(e.fn, vals, ev.rand), nil
+			}
+		}
+		return call{e.fn, args}.Eval(nil), nil
+	}
+	return e.Eval(ev.env), nil
+}
@@ -0,0 +1,229 @@
+// Package expr parses, checks, transforms and evaluates arithmetic
+// expressions over float64 variables, e.g. pow(x + y, 2) / 3.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// An Env that mapping var -> value
+type Env map[Var]float64
+
+// An Expr interface is an arithmetic expression.
+type Expr interface {
+	Eval(env Env) float64
+	String() string
+	// Check reports every static error in the expression as a
+	// Diagnostics and records the variables it reads in vars.
+	Check(vars map[Var]bool) error
+}
+
+// A Var identifies a variable, e.g., x.
+type Var string
+
+func (v Var) Eval(env Env) float64 {
+	return env[v]
+}
+
+func (v Var) Check(vars map[Var]bool) error {
+	vars[v] = true
+	return nil
+}
+
+func (v Var) String() string {
+	if !Debug {
+		return Format(v)
+	}
+	return "变量:" + string(v)
+}
+
+// A literal is a numeric constant, e.g., 3.141.
+type literal float64
+
+func (l literal) Eval(env Env) float64 {
+	return float64(l)
+}
+
+func (literal) Check(vars map[Var]bool) error {
+	return nil
+}
+
+func (l literal) String() string {
+	if !Debug {
+		return Format(l)
+	}
+	return "常量:" + DefaultNumberFormat.Format(float64(l))
+
+}
+
+// A unary represents a unary operator expression, e.g., -x.
+type unary struct {
+	op rune // one of '+' | '-'
+	x  Expr
+}
+
+func (u unary) Eval(env Env) float64 {
+	switch u.op {
+	case '+':
+		return +u.x.Eval(env)
+	case '-':
+		return -u.x.Eval(env)
+	}
+	panic(fmt.Sprintf("unsupported unary operator: %q", u.op))
+}
+
+func (u unary) Check(vars map[Var]bool) error {
+	return check(u, vars)
+}
+
+func (u unary) String() string {
+	if !Debug {
+		return Format(u)
+	}
+	return "(操作符号:" + strconv.QuoteRuneToASCII(u.op) + " | " +
+		u.x.String() + ")"
+
+}
+
+// A binary represents a binary operator expression, e.g., x+y.
+type binary struct {
+	op   rune // one of '+', '-', '*', '/'
+	x, y Expr
+}
+
+func (b binary) Eval(env Env) float64 {
+	switch b.op {
+	case '+':
+		return b.x.Eval(env) + b.y.Eval(env)
+	case '-':
+		return b.x.Eval(env) - b.y.Eval(env)
+	case '*':
+		return b.x.Eval(env) * b.y.Eval(env)
+	case '/':
+		return b.x.Eval(env) / b.y.Eval(env)
+	}
+	panic(fmt.Sprintf("unsupported unary operator: %q", b.op))
+}
+
+func (b binary) Check(vars map[Var]bool) error {
+	return check(b, vars)
+}
+
+func (b binary) String() string {
+	if !Debug {
+		return Format(b)
+	}
+	return "(" + b.x.String() + " | 操作符号:" +
+		strconv.QuoteRuneToASCII(b.op) + " | " + b.y.String() + ")"
+
+}
+
+// A call represents a function call expression, e.g., sin(x).
+type call struct {
+	fn   string // one of the keys of numParams or minParams
+	args []Expr
+}
+
+func (c call) Eval(env Env) float64 {
+	switch c.fn {
+	case "pow":
+		return math.Pow(c.args[0].Eval(env), c.args[1].Eval(env))
+	case "sin":
+		return math.Sin(c.args[0].Eval(env))
+	case "sqrt":
+		return math.Sqrt(c.args[0].Eval(env))
+	case "rand", "randint", "normal":
+		args := make([]float64, len(c.args))
+		for i, arg := range c.args {
+			args[i] = arg.Eval(env)
+		}
+		return randomCall(c.fn, args, globalRand{})
+	case "now", "date":
+		args := make([]float64, len(c.args))
+		for i, arg := range c.args {
+			args[i] = arg.Eval(env)
+		}
+		return timeCall(c.fn, args)
+	case "mean", "median", "variance", "stddev", "percentile":
+		args := make([]float64, len(c.args))
+		for i, arg := range c.args {
+			args[i] = arg.Eval(env)
+		}
+		return statsCall(c.fn, args)
+	}
+	panic(fmt.Sprintf("unsupported function call: %s", c.fn))
+}
+
+var numParams = map[string]int{
+	"pow": 2, "sin": 1, "sqrt": 1,
+	"rand": 0, "randint": 2, "normal": 2,
+	"now": 0, "date": 3,
+}
+
+func (c call) Check(vars map[Var]bool) error {
+	return check(c, vars)
+}
+
+// arity reports a call to a builtin with the wrong number of arguments,
+// which Eval cannot evaluate.
+func (c call) arity() error {
+	if min, ok := minParams[c.fn]; ok {
+		if len(c.args) < min {
+			return fmt.Errorf("call to %s has %d args, want at least %d", c.fn, len(c.args), min)
+		}
+	} else if n, ok := numParams[c.fn]; ok && len(c.args) != n {
+		return fmt.Errorf("call to %s has %d args, want %d", c.fn, len(c.args), n)
+	}
+	return nil
+}
+
+func (c call) String() string {
+	if !Debug {
+		return Format(c)
+	}
+	var args string
+	for i, v := range c.args {
+		args += v.String()
+		if i < len(c.args)-1 {
+			args += ", "
+		}
+	}
+	//fmt.Println(args)
+	return "函数:" + c.fn + "(" + args + ")"
+}
+
+// Test Eval
+//func TestEval(t *testing.T) {
+//	tests := []struct {
+//		expr string
+//		env  Env
+//		want string
+//	}{
+//		{"sqrt(A / pi)", Env{"A": 87616, "pi": math.Pi}, "167"},
+//		{"pow(x, 3) + pow(y, 3)", Env{"x": 12, "y": 1}, "1729"},
+//		{"pow(x, 3) + pow(y, 3)", Env{"x": 9, "y": 10}, "1729"},
+//		{"5 / 9 * (F - 32)", Env{"F": -40}, "-40"},
+//		{"5 / 9 * (F - 32)", Env{"F": 32}, "0"},
+//		{"5 / 9 * (F - 32)", Env{"F": 212}, "100"},
+//	}
+//	var prevExpr string
+//	for _, test := range tests {
+//		if test.expr != prevExpr {
+//			fmt.Printf("\n%s\n", test.expr)
+//			prevExpr = test.expr
+//		}
+//		expr, err := Parse(test.expr)
+//		if err != nil {
+//			t.Error(err)
+//			continue
+//		}
+//		got := fmt.Sprintf("%.6g", expr.Eval(test.env))
+//		fmt.Printf("\t%v => %s\n", test.env, got)
+//		if got != test.want {
+//			t.Errorf("%s.Eval() in %v = %q, want %q\n",
+//				test.expr, test.env, got, test.want)
+//		}
+//	}
+//}
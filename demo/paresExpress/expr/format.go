@@ -0,0 +1,99 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Debug makes String print the verbose debug form of an expression,
+// e.g. (变量:x | 操作符号:'+' | 变量:y), instead of the infix notation
+// produced by Format.
+var Debug = false
+
+// Precedence levels of infix notation, from loosest to tightest binding.
+const (
+	precSum = iota + 1
+	precProduct
+	precUnary
+	precAtom
+)
+
+// Format prints e in conventional infix notation, e.g. pow(x + y, 2) / 3,
+// with only the parentheses required by operator precedence.
+// Operators are left-associative, so a right operand of the same
+// precedence keeps its parentheses: x - (y - z).
+func Format(e Expr) string {
+	var b strings.Builder
+	writeInfix(&b, e)
+	return b.String()
+}
+
+func writeInfix(b *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case Var:
+		b.WriteString(string(e))
+	case literal:
+		b.WriteString(strconv.FormatFloat(float64(e), 'g', -1, 64))
+	case unary:
+		b.WriteRune(e.op)
+		if l, ok := e.x.(literal); ok && l >= 0 {
+			// Keep -(2) distinct from the negative constant -2.
+			writeParen(b, e.x)
+			return
+		}
+		writeOperand(b, e.x, precUnary)
+	case binary:
+		prec := infixPrec(e)
+		writeOperand(b, e.x, prec)
+		b.WriteByte(' ')
+		b.WriteRune(e.op)
+		b.WriteByte(' ')
+		writeOperand(b, e.y, prec+1)
+	case call:
+		b.WriteString(e.fn)
+		b.WriteByte('(')
+		for i, arg := range e.args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeInfix(b, arg)
+		}
+		b.WriteByte(')')
+	default:
+		b.WriteString(e.String())
+	}
+}
+
+// writeOperand writes e, parenthesized if it binds looser than prec.
+func writeOperand(b *strings.Builder, e Expr, prec int) {
+	if infixPrec(e) < prec {
+		writeParen(b, e)
+		return
+	}
+	writeInfix(b, e)
+}
+
+func writeParen(b *strings.Builder, e Expr) {
+	b.WriteByte('(')
+	writeInfix(b, e)
+	b.WriteByte(')')
+}
+
+// infixPrec returns the precedence of the top-level operator of e.
+func infixPrec(e Expr) int {
+	switch e := e.(type) {
+	case literal:
+		if e < 0 {
+			return precUnary
+		}
+	case unary:
+		return precUnary
+	case binary:
+		switch e.op {
+		case '+', '-':
+			return precSum
+		}
+		return precProduct
+	}
+	return precAtom
+}
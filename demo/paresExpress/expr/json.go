@@ -0,0 +1,215 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Expressions are encoded as JSON objects tagged with their node type:
+//
+//	{"type": "var", "name": "x"}
+//	{"type": "literal", "value": 3.141}
+//	{"type": "unary", "op": "-", "x": {...}}
+//	{"type": "binary", "op": "+", "x": {...}, "y": {...}}
+//	{"type": "call", "fn": "pow", "args": [{...}, {...}]}
+type jsonNode struct {
+	Type  string            `json:"type"`
+	Name  string            `json:"name,omitempty"`
+	Value *float64          `json:"value,omitempty"`
+	Op    string            `json:"op,omitempty"`
+	X     json.RawMessage   `json:"x,omitempty"`
+	Y     json.RawMessage   `json:"y,omitempty"`
+	Fn    string            `json:"fn,omitempty"`
+	Args  []json.RawMessage `json:"args,omitempty"`
+}
+
+func (v Var) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}{"var", string(v)})
+}
+
+func (l literal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string  `json:"type"`
+		Value float64 `json:"value"`
+	}{"literal", float64(l)})
+}
+
+func (u unary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Op   string `json:"op"`
+		X    Expr   `json:"x"`
+	}{"unary", string(u.op), u.x})
+}
+
+func (b binary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Op   string `json:"op"`
+		X    Expr   `json:"x"`
+		Y    Expr   `json:"y"`
+	}{"binary", string(b.op), b.x, b.y})
+}
+
+func (c call) MarshalJSON() ([]byte, error) {
+	args := c.args
+	if args == nil {
+		args = []Expr{}
+	}
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Fn   string `json:"fn"`
+		Args []Expr `json:"args"`
+	}{"call", c.fn, args})
+}
+
+func (v *Var) UnmarshalJSON(data []byte) error     { return unmarshalNode(data, v) }
+func (l *literal) UnmarshalJSON(data []byte) error { return unmarshalNode(data, l) }
+func (u *unary) UnmarshalJSON(data []byte) error   { return unmarshalNode(data, u) }
+func (b *binary) UnmarshalJSON(data []byte) error  { return unmarshalNode(data, b) }
+func (c *call) UnmarshalJSON(data []byte) error    { return unmarshalNode(data, c) }
+
+// unmarshalNode decodes data and stores it in dst, which must point to a
+// node of the same type as the one encoded.
+func unmarshalNode(data []byte, dst interface{}) error {
+	e, err := UnmarshalExpr(data)
+	if err != nil {
+		return err
+	}
+	switch dst := dst.(type) {
+	case *Var:
+		if v, ok := e.(Var); ok {
+			*dst = v
+			return nil
+		}
+	case *literal:
+		if l, ok := e.(literal); ok {
+			*dst = l
+			return nil
+		}
+	case *unary:
+		if u, ok := e.(unary); ok {
+			*dst = u
+			return nil
+		}
+	case *binary:
+		if b, ok := e.(binary); ok {
+			*dst = b
+			return nil
+		}
+	case *call:
+		if c, ok := e.(call); ok {
+			*dst = c
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot unmarshal %T into %T", e, dst)
+}
+
+// UnmarshalExpr reconstructs an expression from its JSON encoding.
+func UnmarshalExpr(data []byte) (Expr, error) {
+	var n jsonNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	switch n.Type {
+	case "var":
+		if n.Name == "" {
+			return nil, fmt.Errorf("var node without name")
+		}
+		return Var(n.Name), nil
+	case "literal":
+		if n.Value == nil {
+			return nil, fmt.Errorf("literal node without value")
+		}
+		return literal(*n.Value), nil
+	case "unary":
+		op, err := decodeOp(n.Op)
+		if err != nil {
+			return nil, err
+		}
+		x, err := decodeOperand("x", n.X)
+		if err != nil {
+			return nil, err
+		}
+		return unary{op, x}, nil
+	case "binary":
+		op, err := decodeOp(n.Op)
+		if err != nil {
+			return nil, err
+		}
+		x, err := decodeOperand("x", n.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeOperand("y", n.Y)
+		if err != nil {
+			return nil, err
+		}
+		return binary{op, x, y}, nil
+	case "call":
+		if n.Fn == "" {
+			return nil, fmt.Errorf("call node without fn")
+		}
+		args := make([]Expr, len(n.Args))
+		for i, raw := range n.Args {
+			arg, err := UnmarshalExpr(raw)
+			if err != nil {
+				return nil, fmt.Errorf("args[%d]: %v", i, err)
+			}
+			args[i] = arg
+		}
+		return call{n.Fn, args}, nil
+	}
+	return nil, fmt.Errorf("unknown node type %q", n.Type)
+}
+
+func decodeOp(s string) (rune, error) {
+	op, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) {
+		return 0, fmt.Errorf("invalid operator %q", s)
+	}
+	return op, nil
+}
+
+func decodeOperand(name string, raw json.RawMessage) (Expr, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("missing operand %s", name)
+	}
+	x, err := UnmarshalExpr(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return x, nil
+}
+
+// A JSONExpr wraps an Expr so that it can be embedded in other JSON
+// documents, such as configuration structs, and decoded without knowing
+// the concrete node type in advance.
+type JSONExpr struct {
+	Expr
+}
+
+func (j JSONExpr) MarshalJSON() ([]byte, error) {
+	if j.Expr == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(j.Expr)
+}
+
+func (j *JSONExpr) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		j.Expr = nil
+		return nil
+	}
+	e, err := UnmarshalExpr(data)
+	if err != nil {
+		return err
+	}
+	j.Expr = e
+	return nil
+}
@@ -0,0 +1,110 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Precedence levels of rendered LaTeX, from loosest to tightest binding.
+const (
+	texSum = iota + 1
+	texProduct
+	texUnary
+	texPower
+	texFrac
+	texAtom
+)
+
+// latexNames maps variable names to LaTeX symbols.
+var latexNames = map[string]string{
+	"alpha": `\alpha`, "beta": `\beta`, "gamma": `\gamma`, "delta": `\delta`,
+	"theta": `\theta`, "lambda": `\lambda`, "mu": `\mu`, "pi": `\pi`,
+	"sigma": `\sigma`, "phi": `\phi`, "omega": `\omega`,
+}
+
+// latexFuncs maps function names to LaTeX operators.
+var latexFuncs = map[string]string{"sin": `\sin`}
+
+// ToLaTeX renders e as LaTeX math, using \frac for division, \sqrt for
+// square roots and superscripts for powers, with only the parentheses
+// required by precedence.
+func ToLaTeX(e Expr) string {
+	s, _ := latex(e)
+	return s
+}
+
+// latex returns the rendering of e together with its precedence level.
+func latex(e Expr) (string, int) {
+	switch e := e.(type) {
+	case Var:
+		if sym, ok := latexNames[string(e)]; ok {
+			return sym, texAtom
+		}
+		if len(e) > 1 {
+			return `\mathrm{` + string(e) + `}`, texAtom
+		}
+		return string(e), texAtom
+	case literal:
+		s := strconv.FormatFloat(float64(e), 'g', -1, 64)
+		if i := strings.IndexByte(s, 'e'); i >= 0 {
+			exp := strings.TrimPrefix(s[i+1:], "+")
+			s = s[:i] + ` \times 10^{` + exp + `}`
+			return s, texProduct
+		}
+		if e < 0 {
+			return s, texUnary
+		}
+		return s, texAtom
+	case unary:
+		x := latexOperand(e.x, texUnary)
+		if e.op == '+' {
+			return "+" + x, texUnary
+		}
+		return "-" + x, texUnary
+	case binary:
+		switch e.op {
+		case '+', '-':
+			x := latexOperand(e.x, texSum)
+			y := latexOperand(e.y, texSum+1)
+			return x + " " + string(e.op) + " " + y, texSum
+		case '*':
+			x := latexOperand(e.x, texProduct)
+			y := latexOperand(e.y, texProduct+1)
+			return x + ` \cdot ` + y, texProduct
+		case '/':
+			x, _ := latex(e.x)
+			y, _ := latex(e.y)
+			return `\frac{` + x + `}{` + y + `}`, texFrac
+		}
+		x := latexOperand(e.x, texProduct)
+		y := latexOperand(e.y, texProduct+1)
+		return x + " " + string(e.op) + " " + y, texProduct
+	case call:
+		args := make([]string, len(e.args))
+		for i, arg := range e.args {
+			args[i], _ = latex(arg)
+		}
+		switch {
+		case e.fn == "sqrt" && len(args) == 1:
+			return `\sqrt{` + args[0] + `}`, texAtom
+		case e.fn == "pow" && len(args) == 2:
+			base := latexOperand(e.args[0], texAtom)
+			return base + `^{` + args[1] + `}`, texPower
+		}
+		name, ok := latexFuncs[e.fn]
+		if !ok {
+			name = `\operatorname{` + e.fn + `}`
+		}
+		return name + `\left(` + strings.Join(args, ", ") + `\right)`, texAtom
+	}
+	return e.String(), texAtom
+}
+
+// latexOperand renders e, parenthesized if it binds looser than prec.
+func latexOperand(e Expr, prec int) string {
+	s, p := latex(e)
+	if p < prec {
+		return `\left(` + s + `\right)`
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package expr
+
+import "fmt"
+
+// Limits bound the size of expressions accepted by Parse and Evaluate,
+// so that deeply nested or oversized input from untrusted sources cannot
+// exhaust the stack or hang the caller. A zero field means no limit.
+type Limits struct {
+	MaxDepth    int // nesting depth of the tree; a single node has depth 1
+	MaxNodes    int // total number of nodes in the tree
+	MaxInputLen int // length of the source text in bytes, for Parse
+}
+
+// DefaultLimits are applied by Parse and Evaluate unless overridden.
+var DefaultLimits = Limits{
+	MaxDepth:    200,
+	MaxNodes:    10000,
+	MaxInputLen: 64 << 10,
+}
+
+// A LimitError reports that an expression exceeds one of its Limits.
+type LimitError struct {
+	What string // "depth", "node count" or "input length"
+	Max  int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("expression exceeds maximum %s %d", e.What, e.Max)
+}
+
+// Check reports a *LimitError if the tree e is deeper or larger than l
+// allows. It stops walking as soon as a limit is exceeded.
+func (l Limits) Check(e Expr) error {
+	nodes := 0
+	var walk func(e Expr, depth int) error
+	walk = func(e Expr, depth int) error {
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return &LimitError{"depth", l.MaxDepth}
+		}
+		nodes++
+		if l.MaxNodes > 0 && nodes > l.MaxNodes {
+			return &LimitError{"node count", l.MaxNodes}
+		}
+		for _, child := range Children(e) {
+			if err := walk(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(e, 1)
+}
+
+// ParseLimits replaces DefaultLimits for one call to Parse.
+func ParseLimits(l Limits) ParseOption {
+	return func(lex *lexer) { lex.limits = l }
+}
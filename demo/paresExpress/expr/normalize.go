@@ -0,0 +1,159 @@
+package expr
+
+import "sort"
+
+// Normalize rewrites e into a canonical form so that expressions that
+// differ only in the order of commutative operands, in the grouping of
+// nested sums and products, or in the placement of unary signs print and
+// compare identically:
+//
+//   - operands of + and * chains are flattened and sorted deterministically;
+//   - unary plus is dropped and double negation removed;
+//   - signs are pulled out of products and absorbed into sums, so that
+//     x + -y becomes x - y and -2 * x becomes -(2 * x);
+//   - negated literals are folded into negative constants.
+//
+// The result is equal to e in exact arithmetic; reordering floating-point
+// operations may change the last bits of a result.
+func Normalize(e Expr) Expr {
+	switch e := e.(type) {
+	case unary:
+		x := Normalize(e.x)
+		if e.op == '-' {
+			return negate(x)
+		}
+		return x
+	case binary:
+		switch e.op {
+		case '+', '-':
+			return normalizeSum(e)
+		case '*':
+			return normalizeProduct(e)
+		}
+		return binary{e.op, Normalize(e.x), Normalize(e.y)}
+	case call:
+		args := make([]Expr, len(e.args))
+		for i, arg := range e.args {
+			args[i] = Normalize(arg)
+		}
+		return call{e.fn, args}
+	}
+	return e
+}
+
+// A term is one signed operand of a flattened sum.
+type term struct {
+	neg bool
+	x   Expr
+}
+
+func normalizeSum(e Expr) Expr {
+	terms := sumTerms(e, false, nil)
+	sort.SliceStable(terms, func(i, j int) bool {
+		return exprKey(terms[i].x) < exprKey(terms[j].x)
+	})
+
+	// Lead with the first positive term to avoid a leading unary minus.
+	head := 0
+	for i, t := range terms {
+		if !t.neg {
+			head = i
+			break
+		}
+	}
+	sum := terms[head].x
+	if terms[head].neg {
+		sum = negate(sum)
+	}
+	for i, t := range terms {
+		if i == head {
+			continue
+		}
+		op := '+'
+		if t.neg {
+			op = '-'
+		}
+		sum = binary{op, sum, t.x}
+	}
+	return sum
+}
+
+func sumTerms(e Expr, neg bool, terms []term) []term {
+	switch e := e.(type) {
+	case binary:
+		switch e.op {
+		case '+':
+			terms = sumTerms(e.x, neg, terms)
+			return sumTerms(e.y, neg, terms)
+		case '-':
+			terms = sumTerms(e.x, neg, terms)
+			return sumTerms(e.y, !neg, terms)
+		}
+	case unary:
+		if e.op == '-' {
+			return sumTerms(e.x, !neg, terms)
+		}
+		return sumTerms(e.x, neg, terms)
+	}
+	x := Normalize(e)
+	if u, ok := x.(unary); ok && u.op == '-' {
+		neg, x = !neg, u.x
+	}
+	if l, ok := x.(literal); ok && l < 0 {
+		neg, x = !neg, -l
+	}
+	return append(terms, term{neg, x})
+}
+
+func normalizeProduct(e Expr) Expr {
+	var neg bool
+	factors := productFactors(e, &neg, nil)
+	sort.SliceStable(factors, func(i, j int) bool {
+		return exprKey(factors[i]) < exprKey(factors[j])
+	})
+	product := factors[0]
+	for _, f := range factors[1:] {
+		product = binary{'*', product, f}
+	}
+	if neg {
+		return negate(product)
+	}
+	return product
+}
+
+func productFactors(e Expr, neg *bool, factors []Expr) []Expr {
+	switch e := e.(type) {
+	case binary:
+		if e.op == '*' {
+			factors = productFactors(e.x, neg, factors)
+			return productFactors(e.y, neg, factors)
+		}
+	case unary:
+		if e.op == '-' {
+			*neg = !*neg
+		}
+		return productFactors(e.x, neg, factors)
+	}
+	x := Normalize(e)
+	if u, ok := x.(unary); ok && u.op == '-' {
+		*neg = !*neg
+		return productFactors(u.x, neg, factors)
+	}
+	if l, ok := x.(literal); ok && l < 0 {
+		*neg, x = !*neg, -l
+	}
+	return append(factors, x)
+}
+
+// negate returns the canonical negation of the normalized expression x.
+func negate(x Expr) Expr {
+	switch x := x.(type) {
+	case literal:
+		return -x
+	case unary:
+		if x.op == '-' {
+			return x.x
+		}
+	}
+	return unary{'-', x}
+}
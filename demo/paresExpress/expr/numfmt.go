@@ -0,0 +1,78 @@
+package expr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A NumberFormat describes how evaluation results are converted to text.
+// The zero value formats like DefaultNumberFormat.
+type NumberFormat struct {
+	// Notation is 'f' for fixed-point, 'e' for scientific notation, or
+	// 'g' for whichever of the two is more compact. Zero means 'f'.
+	Notation byte
+	// Precision is the number of digits after the decimal point for 'f'
+	// and 'e', or the number of significant digits for 'g'. Zero or -1
+	// selects the fewest digits that represent the value exactly; round
+	// the value first to format it without a fractional part.
+	Precision int
+	// Thousands, if not empty, separates groups of three digits in the
+	// integer part, e.g. "," for 1,234,567.
+	Thousands string
+	// DecimalComma writes the decimal separator as a comma, as in many
+	// European locales: 3,14.
+	DecimalComma bool
+}
+
+// DefaultNumberFormat is used for literals in debug output.
+var DefaultNumberFormat = NumberFormat{Notation: 'f', Precision: -1}
+
+// Format formats v according to f.
+func (f NumberFormat) Format(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	notation, precision := f.Notation, f.Precision
+	if notation == 0 {
+		notation = 'f'
+	}
+	if precision == 0 {
+		precision = -1
+	}
+	s := strconv.FormatFloat(math.Abs(v), notation, precision, 64)
+
+	var exp string
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s, exp = s[:i], s[i:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i+1:]
+	}
+
+	var b strings.Builder
+	if math.Signbit(v) {
+		b.WriteByte('-')
+	}
+	if f.Thousands != "" {
+		for i, d := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				b.WriteString(f.Thousands)
+			}
+			b.WriteRune(d)
+		}
+	} else {
+		b.WriteString(intPart)
+	}
+	if frac != "" {
+		if f.DecimalComma {
+			b.WriteByte(',')
+		} else {
+			b.WriteByte('.')
+		}
+		b.WriteString(frac)
+	}
+	b.WriteString(exp)
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package expr
+
+import "testing"
+
+func TestNumberFormat(t *testing.T) {
+	tests := []struct {
+		format NumberFormat
+		v      float64
+		want   string
+	}{
+		{NumberFormat{}, 1234.5, "1234.5"},
+		{NumberFormat{}, 0.1, "0.1"},
+		{NumberFormat{Thousands: ","}, 1234567.25, "1,234,567.25"},
+		{NumberFormat{Notation: 'f', Precision: 2}, 3.14159, "3.14"},
+		{NumberFormat{Notation: 'e', Precision: 3}, 10260, "1.026e+04"},
+		{NumberFormat{Notation: 'g'}, 1e21, "1e+21"},
+		{NumberFormat{DecimalComma: true}, -2.5, "-2,5"},
+	}
+	for _, test := range tests {
+		if got := test.format.Format(test.v); got != test.want {
+			t.Errorf("%+v.Format(%g) = %s, want %s", test.format, test.v, got, test.want)
+		}
+	}
+	if got, want := (NumberFormat{}).Format(1234.5), DefaultNumberFormat.Format(1234.5); got != want {
+		t.Errorf("zero NumberFormat gives %s, DefaultNumberFormat %s", got, want)
+	}
+}
@@ -0,0 +1,383 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+// ---- lexer ----
+
+// A lexer wraps text/scanner with one token of lookahead. Numbers are
+// scanned by the lexer itself, see scanNumber.
+type lexer struct {
+	scan   scanner.Scanner
+	input  string
+	token  rune   // current lookahead token
+	num    string // text of the current token if it is a number
+	offset int    // byte offset of the current token
+
+	implicitMul bool // juxtaposition means multiplication
+	limits      Limits
+	depth       int // current nesting of parseUnary
+}
+
+func (lex *lexer) next() {
+	s := &lex.scan
+	for ch := s.Peek(); ch >= 0 && ch < 64 && s.Whitespace&(1<<uint(ch)) != 0; ch = s.Peek() {
+		s.Next()
+	}
+	lex.offset = s.Pos().Offset
+	if n, float := scanNumber(lex.input[lex.offset:]); n > 0 {
+		for i := 0; i < n; i++ {
+			s.Next()
+		}
+		lex.num = lex.input[lex.offset : lex.offset+n]
+		lex.token = scanner.Int
+		if float {
+			lex.token = scanner.Float
+		}
+		return
+	}
+	lex.num = ""
+	lex.token = s.Scan()
+	lex.offset = s.Position.Offset
+}
+
+func (lex *lexer) text() string {
+	if lex.num != "" {
+		return lex.num
+	}
+	return lex.scan.TokenText()
+}
+
+// scanNumber returns the length of the number at the start of s, 0 if
+// there is none, and whether it is a floating-point literal. It follows
+// Go literal syntax, but takes no letters beyond the number itself: 2pi
+// is the number 2 followed by pi, and the exponent of 2e3 needs its
+// digits, so 2e is 2 followed by e. text/scanner would instead read the
+// letters as a malformed exponent.
+func scanNumber(s string) (n int, float bool) {
+	i := 0
+	digits := func(valid func(byte) bool) {
+		for i < len(s) && (valid(s[i]) || s[i] == '_') {
+			i++
+		}
+	}
+	// exponent consumes an exponent marked by one of the bytes of marks,
+	// provided it has digits.
+	exponent := func(marks string) {
+		if i >= len(s) || strings.IndexByte(marks, s[i]) < 0 {
+			return
+		}
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDecimal(s[j]) {
+			i, float = j, true
+			digits(isDecimal)
+		}
+	}
+
+	if len(s) > 1 && s[0] == '0' && strings.IndexByte("xXbBoO", s[1]) >= 0 {
+		i = 2
+		if s[1] != 'x' && s[1] != 'X' {
+			// Digits out of range are reported when the number is converted.
+			digits(isDecimal)
+			return i, false
+		}
+		digits(isHex)
+		if i < len(s) && s[i] == '.' {
+			i, float = i+1, true
+			digits(isHex)
+		}
+		exponent("pP")
+		return i, float
+	}
+
+	digits(isDecimal)
+	if i < len(s) && s[i] == '.' && (i > 0 || len(s) > 1 && isDecimal(s[1])) {
+		i, float = i+1, true
+		digits(isDecimal)
+	}
+	if i == 0 {
+		return 0, false
+	}
+	exponent("eE")
+	return i, float
+}
+
+func isDecimal(c byte) bool { return '0' <= c && c <= '9' }
+
+func isHex(c byte) bool {
+	return isDecimal(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+type lexPanic string
+
+// describe returns a string describing the current token, for use in errors.
+func (lex *lexer) describe() string {
+	switch lex.token {
+	case scanner.EOF:
+		return "end of file"
+	case scanner.Ident:
+		return fmt.Sprintf("identifier %s", lex.text())
+	case scanner.Int, scanner.Float:
+		return fmt.Sprintf("number %s", lex.text())
+	}
+	return fmt.Sprintf("%q", rune(lex.token)) // any other rune
+}
+
+func precedence(op rune) int {
+	switch op {
+	case '*', '/':
+		return 2
+	case '+', '-':
+		return 1
+	}
+	return 0
+}
+
+// operator returns the binary operator at the current token and its
+// precedence. With implicit multiplication enabled, a token that starts
+// an operand is reported as a '*' that is not consumed; real reports
+// whether the operator is an actual token.
+func (lex *lexer) operator() (op rune, prec int, real bool) {
+	if prec := precedence(lex.token); prec > 0 {
+		return lex.token, prec, true
+	}
+	if lex.implicitMul {
+		switch lex.token {
+		case scanner.Ident, scanner.Int, scanner.Float, '(':
+			return '*', precedence('*'), false
+		}
+	}
+	return 0, 0, false
+}
+
+// numberAtom reports whether tok, a whole token of ParseRPN or
+// ParseSExpr, is a number and returns its value. Numbers and duration
+// literals follow the syntax of Parse, optionally preceded by a minus
+// sign, as ToSExpr writes negative literals.
+func numberAtom(tok string) (v float64, ok bool, err error) {
+	digits := strings.TrimPrefix(tok, "-")
+	n, float := scanNumber(digits)
+	if seconds, ok := durationAtom(digits, n); ok {
+		v = seconds
+	} else if n == 0 || n != len(digits) {
+		return 0, false, nil
+	} else if v, err = numberValue(digits, float); err != nil {
+		return 0, true, err
+	}
+	if digits != tok {
+		v = -v
+	}
+	return v, true, nil
+}
+
+// ---- parser ----
+
+// A ParseOption configures the infix parser.
+type ParseOption func(*lexer)
+
+// ImplicitMultiplication makes Parse accept textbook-style products
+// written by juxtaposition, such as 2x, 2(x+1) and (x+1)(x-1). An
+// identifier directly followed by '(' is still a call if it names a known
+// function, so sin(x) is a call while a(x+1) is a*(x+1).
+//
+// A number directly followed by e or E and digits is read as an exponent,
+// so 2e3 is 2000 and 2e-3 is 0.002, while 2e and 2pi are products.
+// Likewise duration literals take precedence: 2h is two hours, not 2 * h.
+func ImplicitMultiplication() ParseOption {
+	return func(lex *lexer) { lex.implicitMul = true }
+}
+
+// Parse parses the input string as an arithmetic expression.
+//
+//	expr = num                         a literal number, e.g., 3.14159, 0xFF
+//	     | num units                   a duration in seconds, e.g., 1h30m
+//	     | id                          a variable name, e.g., x
+//	     | id '(' expr ',' ... ')'     a function call
+//	     | '-' expr                    a unary operator (+-)
+//	     | expr '+' expr               a binary operator (+-*/)
+//
+// A minus sign immediately applied to a number yields a negative literal,
+// so Parse(Format(e)) reproduces e for every expression e whose literals
+// are finite. Infinities and NaN, which PartialEval may fold a constant
+// subexpression into, have no infix form.
+//
+// Input and the resulting tree must be within DefaultLimits, or the
+// limits given by ParseLimits; otherwise Parse returns a *LimitError.
+func Parse(input string, opts ...ParseOption) (_ Expr, err error) {
+	defer func() {
+		switch x := recover().(type) {
+		case nil:
+			// no panic
+		case lexPanic:
+			err = fmt.Errorf("%s", x)
+		case *LimitError:
+			err = x
+		default:
+			// unexpected panic: resume state of panic.
+			panic(x)
+		}
+	}()
+	lex := &lexer{input: input, limits: DefaultLimits}
+	for _, opt := range opts {
+		opt(lex)
+	}
+	if max := lex.limits.MaxInputLen; max > 0 && len(input) > max {
+		return nil, &LimitError{"input length", max}
+	}
+	lex.scan.Init(strings.NewReader(input))
+	lex.scan.Mode = scanner.ScanIdents
+	lex.scan.Error = func(s *scanner.Scanner, msg string) {
+		panic(lexPanic(fmt.Sprintf("%s: %s", s.Pos(), msg)))
+	}
+	lex.next() // initial lookahead
+	e := parseExpr(lex)
+	if lex.token != scanner.EOF {
+		return nil, fmt.Errorf("unexpected %s", lex.describe())
+	}
+	if err := lex.limits.Check(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func parseExpr(lex *lexer) Expr { return parseBinary(lex, 1) }
+
+// binary = unary ('+' binary)*
+// parseBinary stops when it encounters an
+// operator of lower precedence than prec1.
+func parseBinary(lex *lexer, prec1 int) Expr {
+	lhs := parseUnary(lex)
+	_, prec, _ := lex.operator()
+	for ; prec >= prec1; prec-- {
+		for {
+			op, p, real := lex.operator()
+			if p != prec {
+				break
+			}
+			if real {
+				lex.next() // consume operator
+			}
+			rhs := parseBinary(lex, prec+1)
+			lhs = binary{op, lhs, rhs}
+		}
+	}
+	return lhs
+}
+
+// unary = '+' expr | primary
+func parseUnary(lex *lexer) Expr {
+	// Every level of nesting passes through here; give up before
+	// recursing deeper than the tree would be allowed to be.
+	lex.depth++
+	defer func() { lex.depth-- }()
+	if max := lex.limits.MaxDepth; max > 0 && lex.depth > max {
+		panic(&LimitError{"depth", max})
+	}
+
+	if lex.token == '+' || lex.token == '-' {
+		op := lex.token
+		lex.next() // consume '+' or '-'
+		if op == '-' && (lex.token == scanner.Int || lex.token == scanner.Float) {
+			return -parseQuantity(lex)
+		}
+		return unary{op, parseUnary(lex)}
+	}
+	return parsePrimary(lex)
+}
+
+// primary = id
+//
+//	| id '(' expr ',' ... ',' expr ')'
+//	| num
+//	| '(' expr ')'
+func parsePrimary(lex *lexer) Expr {
+	switch lex.token {
+	case scanner.Ident:
+		id := lex.text()
+		lex.next() // consume Ident
+		if lex.token != '(' {
+			return Var(id)
+		}
+		if lex.implicitMul && !knownFunc(id) {
+			return Var(id)
+		}
+		lex.next() // consume '('
+		var args []Expr
+		if lex.token != ')' {
+			for {
+				args = append(args, parseExpr(lex))
+				if lex.token != ',' {
+					break
+				}
+				lex.next() // consume ','
+			}
+			if lex.token != ')' {
+				msg := fmt.Sprintf("got %s, want ')'", lex.describe())
+				panic(lexPanic(msg))
+			}
+		}
+		lex.next() // consume ')'
+		return call{id, args}
+
+	case scanner.Int, scanner.Float:
+		return parseQuantity(lex)
+
+	case '(':
+		lex.next() // consume '('
+		e := parseExpr(lex)
+		if lex.token != ')' {
+			msg := fmt.Sprintf("got %s, want ')'", lex.describe())
+			panic(lexPanic(msg))
+		}
+		lex.next() // consume ')'
+		return e
+	}
+	msg := fmt.Sprintf("unexpected %s", lex.describe())
+	panic(lexPanic(msg))
+}
+
+// quantity = num | num units
+func parseQuantity(lex *lexer) literal {
+	text, offset := lex.text(), lex.offset
+	n := parseNumber(lex)
+	if seconds, ok := parseDuration(lex, text, offset); ok {
+		return literal(seconds)
+	}
+	return n
+}
+
+// num = int | float
+//
+// Numbers use Go literal syntax: decimal and scientific notation such
+// as 1.5e-3, hexadecimal 0xFF, octal 0o17, binary 0b1010, and underscores
+// between digits, as in 1_000_000. A leading zero does not select octal.
+func parseNumber(lex *lexer) literal {
+	v, err := numberValue(lex.text(), lex.token == scanner.Float)
+	if err != nil {
+		panic(lexPanic(err.Error()))
+	}
+	lex.next() // consume number
+	return literal(v)
+}
+
+// numberValue returns the value of text, a number as scanned by
+// scanNumber.
+func numberValue(text string, float bool) (float64, error) {
+	if !float && len(text) > 1 && strings.ContainsRune("xXbBoO", rune(text[1])) {
+		i, ok := new(big.Int).SetString(text, 0)
+		if !ok {
+			return 0, fmt.Errorf("invalid number %s", text)
+		}
+		v, _ := new(big.Float).SetInt(i).Float64()
+		return v, nil
+	}
+	return strconv.ParseFloat(text, 64)
+}
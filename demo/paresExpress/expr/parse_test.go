@@ -0,0 +1,59 @@
+package expr
+
+import "testing"
+
+func TestImplicitMultiplication(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // Format of the parsed expression
+	}{
+		{"2x", "2 * x"},
+		{"2pi", "2 * pi"},
+		{"2e", "2 * e"},
+		{"2e3", "2000"},
+		{"2e-3x", "0.002 * x"},
+		{"3(x+1)", "3 * (x + 1)"},
+		{"(x+1)(x-1)", "(x + 1) * (x - 1)"},
+		{"2 sin(x)", "2 * sin(x)"},
+		{"a(x+1)", "a * (x + 1)"},
+		{"0x1Fy", "31 * y"},
+		{"2h", "7200"},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.input, ImplicitMultiplication())
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.input, err)
+			continue
+		}
+		if got := Format(e); got != test.want {
+			t.Errorf("Parse(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"08", 8},
+		{"010", 10},
+		{"0.5", 0.5},
+		{"0o17", 15},
+		{"0x1F", 31},
+		{"0b1010", 10},
+		{"1_000_000", 1e6},
+		{"1.5e-3", 1.5e-3},
+		{".5", 0.5},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.input, err)
+			continue
+		}
+		if got := e.Eval(nil); got != test.want {
+			t.Errorf("Parse(%q) = %g, want %g", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package expr
+
+// PartialEval substitutes the variables bound in known and folds every
+// sub-expression whose operands are all constant, returning a residual
+// expression over the remaining unknown variables. If every variable of e
+// is known, the result is a single literal.
+//
+// The residual expression evaluates, in any environment that agrees with
+// known, to the same value as e. Calls to unknown functions, or with the
+// wrong number of arguments, are left unevaluated for Check to report.
+func PartialEval(e Expr, known Env) Expr {
+	switch e := e.(type) {
+	case Var:
+		if v, ok := known[e]; ok {
+			return literal(v)
+		}
+		return e
+	case literal:
+		return e
+	case unary:
+		x := PartialEval(e.x, known)
+		if isLiteral(x) {
+			return literal(unary{e.op, x}.Eval(nil))
+		}
+		return unary{e.op, x}
+	case binary:
+		x, y := PartialEval(e.x, known), PartialEval(e.y, known)
+		if isLiteral(x) && isLiteral(y) {
+			return literal(binary{e.op, x, y}.Eval(nil))
+		}
+		return binary{e.op, x, y}
+	case call:
+		args := make([]Expr, len(e.args))
+		folded := true
+		for i, arg := range e.args {
+			args[i] = PartialEval(arg, known)
+			folded = folded && isLiteral(args[i])
+		}
+		c := call{e.fn, args}
+		if folded && !impure[e.fn] && builtin(c) {
+			return literal(c.Eval(nil))
+		}
+		return c
+	}
+	return e
+}
+
+func isLiteral(e Expr) bool {
+	_, ok := e.(literal)
+	return ok
+}
+
+// builtin reports whether c calls a known function with an acceptable
+// number of arguments, so that Eval does not panic.
+func builtin(c call) bool {
+	_, fixed := numParams[c.fn]
+	_, variadic := minParams[c.fn]
+	return (fixed || variadic) && c.arity() == nil
+}
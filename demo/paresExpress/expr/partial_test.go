@@ -0,0 +1,20 @@
+package expr
+
+import "testing"
+
+func TestPartialEvalInvalidCalls(t *testing.T) {
+	tests := []struct {
+		e    Expr
+		want string
+	}{
+		{call{"nosuch", []Expr{Var("x")}}, "nosuch(2)"},
+		{call{"sqrt", []Expr{literal(1), Var("x")}}, "sqrt(1, 2)"},
+		{call{"mean", nil}, "mean()"},
+		{call{"pow", []Expr{Var("x"), literal(3)}}, "8"},
+	}
+	for _, test := range tests {
+		if got := Format(PartialEval(test.e, Env{"x": 2})); got != test.want {
+			t.Errorf("PartialEval(%s) = %s, want %s", Format(test.e), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package expr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// A Chart is the graph of an expression over one variable, which can be
+// rendered as ASCII art or exported as SVG.
+type Chart struct {
+	Expr     Expr
+	Var      Var
+	From, To float64
+	Env      Env // values of the other variables
+
+	Width, Height       int // ASCII size in characters
+	SVGWidth, SVGHeight int // SVG size in pixels
+}
+
+// Plot returns a chart of e as v ranges from from to to, with the other
+// variables of e taken from env.
+func Plot(e Expr, v Var, from, to float64, env Env) *Chart {
+	return &Chart{
+		Expr: e, Var: v, From: from, To: to, Env: env,
+		Width: 72, Height: 20,
+		SVGWidth: 640, SVGHeight: 400,
+	}
+}
+
+// sample evaluates the expression at n evenly spaced points.
+func (c *Chart) sample(n int) (xs, ys []float64) {
+	env := make(Env, len(c.Env)+1)
+	for k, v := range c.Env {
+		env[k] = v
+	}
+	xs, ys = make([]float64, n), make([]float64, n)
+	for i := range xs {
+		x := c.From
+		if n > 1 {
+			x += (c.To - c.From) * float64(i) / float64(n-1)
+		}
+		env[c.Var] = x
+		xs[i], ys[i] = x, c.Expr.Eval(env)
+	}
+	return xs, ys
+}
+
+// yRange returns the range of the finite values in ys, widened if the
+// values are all equal. ok is false if there are no finite values.
+func yRange(ys []float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, y := range ys {
+		if !math.IsNaN(y) && !math.IsInf(y, 0) {
+			lo, hi = math.Min(lo, y), math.Max(hi, y)
+		}
+	}
+	if lo > hi {
+		return 0, 0, false
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	return lo, hi, true
+}
+
+// WriteASCII draws the chart with one sample per column, marking the
+// axes where they fall within the plotted range.
+func (c *Chart) WriteASCII(w io.Writer) error {
+	if c.Width < 2 || c.Height < 2 {
+		return fmt.Errorf("chart too small: %dx%d", c.Width, c.Height)
+	}
+	xs, ys := c.sample(c.Width)
+	lo, hi, ok := yRange(ys)
+	if !ok {
+		return fmt.Errorf("%s has no finite values on [%g, %g]", c.Expr, c.From, c.To)
+	}
+
+	row := func(y float64) int {
+		return int(math.Round((hi - y) / (hi - lo) * float64(c.Height-1)))
+	}
+	grid := make([][]byte, c.Height)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(" ", c.Width))
+	}
+	if lo <= 0 && 0 <= hi {
+		r := row(0)
+		for j := range grid[r] {
+			grid[r][j] = '-'
+		}
+	}
+	for j, x := range xs {
+		if j+1 < len(xs) && x <= 0 && 0 < xs[j+1] || x == 0 {
+			for i := range grid {
+				grid[i][j] = '|'
+			}
+			break
+		}
+	}
+	for j, y := range ys {
+		if !math.IsNaN(y) && !math.IsInf(y, 0) {
+			grid[row(y)][j] = '*'
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	labelHi, labelLo := fmt.Sprintf("%.4g", hi), fmt.Sprintf("%.4g", lo)
+	margin := len(labelHi)
+	if len(labelLo) > margin {
+		margin = len(labelLo)
+	}
+	for i, line := range grid {
+		label := ""
+		switch i {
+		case 0:
+			label = labelHi
+		case c.Height - 1:
+			label = labelLo
+		}
+		fmt.Fprintf(bw, "%*s |%s\n", margin, label, line)
+	}
+	from, to := fmt.Sprintf("%.4g", c.From), fmt.Sprintf("%.4g", c.To)
+	pad := c.Width - len(from) - len(to)
+	if pad < 1 {
+		pad = 1
+	}
+	fmt.Fprintf(bw, "%*s  %s%*s%s\n", margin, "", from, pad, "", to)
+	return bw.Flush()
+}
+
+// WriteSVG exports the chart as an SVG document. Gaps where the
+// expression is not finite are left undrawn. The chart must be at least
+// 2 pixels each way and From must differ from To.
+func (c *Chart) WriteSVG(w io.Writer) error {
+	if c.SVGWidth < 2 || c.SVGHeight < 2 {
+		return fmt.Errorf("chart too small: %dx%d", c.SVGWidth, c.SVGHeight)
+	}
+	if c.From == c.To {
+		return fmt.Errorf("empty range [%g, %g]", c.From, c.To)
+	}
+	width, height := float64(c.SVGWidth), float64(c.SVGHeight)
+	xs, ys := c.sample(c.SVGWidth)
+	lo, hi, ok := yRange(ys)
+	if !ok {
+		return fmt.Errorf("%s has no finite values on [%g, %g]", c.Expr, c.From, c.To)
+	}
+	px := func(x float64) float64 { return (x - c.From) / (c.To - c.From) * width }
+	py := func(y float64) float64 { return (hi - y) / (hi - lo) * height }
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		c.SVGWidth, c.SVGHeight, c.SVGWidth, c.SVGHeight)
+	fmt.Fprintf(bw, "<title>%s</title>\n", xmlEscape(Format(c.Expr)))
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	if lo <= 0 && 0 <= hi {
+		fmt.Fprintf(bw, `<line x1="0" y1="%.2f" x2="%.2f" y2="%.2f" stroke="gray"/>`+"\n", py(0), width, py(0))
+	}
+	if c.From <= 0 && 0 <= c.To {
+		fmt.Fprintf(bw, `<line x1="%.2f" y1="0" x2="%.2f" y2="%.2f" stroke="gray"/>`+"\n", px(0), px(0), height)
+	}
+
+	var points []string
+	flush := func() {
+		if len(points) > 1 {
+			fmt.Fprintf(bw, `<polyline fill="none" stroke="steelblue" stroke-width="2" points="%s"/>`+"\n",
+				strings.Join(points, " "))
+		}
+		points = points[:0]
+	}
+	for i, y := range ys {
+		if math.IsNaN(y) || math.IsInf(y, 0) {
+			flush()
+			continue
+		}
+		points = append(points, fmt.Sprintf("%.2f,%.2f", px(xs[i]), py(y)))
+	}
+	flush()
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func xmlEscape(s string) string { return xmlEscaper.Replace(s) }
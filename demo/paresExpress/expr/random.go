@@ -0,0 +1,64 @@
+package expr
+
+import (
+	"math"
+	"math/rand"
+)
+
+// impure lists the functions whose result is not determined by their
+// arguments. Calls to them are never folded into constants or cached.
+var impure = map[string]bool{"rand": true, "randint": true, "normal": true, "now": true}
+
+// A randSource supplies the random numbers of one evaluation.
+type randSource interface {
+	Float64() float64
+	Int63n(n int64) int64
+	NormFloat64() float64
+}
+
+// globalRand draws from the shared, concurrency-safe source of math/rand.
+type globalRand struct{}
+
+func (globalRand) Float64() float64     { return rand.Float64() }
+func (globalRand) Int63n(n int64) int64 { return rand.Int63n(n) }
+func (globalRand) NormFloat64() float64 { return rand.NormFloat64() }
+
+// randomCall evaluates a call to one of the impure functions:
+//
+//	rand()             uniform in [0, 1)
+//	randint(a, b)      uniform integer in [a, b]
+//	normal(mu, sigma)  normally distributed with mean mu and deviation sigma
+func randomCall(fn string, args []float64, r randSource) float64 {
+	switch fn {
+	case "rand":
+		return r.Float64()
+	case "randint":
+		lo, hi := math.Ceil(args[0]), math.Floor(args[1])
+		if !(lo <= hi) || hi-lo >= math.MaxInt64 {
+			return math.NaN()
+		}
+		return lo + float64(r.Int63n(int64(hi-lo)+1))
+	case "normal":
+		return args[0] + args[1]*r.NormFloat64()
+	}
+	panic("unsupported random function: " + fn)
+}
+
+// EvalSeed makes rand, randint and normal draw from a generator of their
+// own, seeded with seed, so that an evaluation is reproducible. Without
+// it they use the global generator of math/rand.
+func EvalSeed(seed int64) EvalOption {
+	return func(ev *evaluator) { ev.rand = rand.New(rand.NewSource(seed)) }
+}
+
+// pure reports whether e contains no calls to impure functions.
+func pure(e Expr) bool {
+	ok := true
+	Walk(e, func(n Expr) bool {
+		if c, isCall := n.(call); isCall && impure[c.fn] {
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
@@ -0,0 +1,46 @@
+package expr
+
+// Lit returns a numeric constant expression.
+func Lit(value float64) Expr { return literal(value) }
+
+// Unary returns the unary operator expression op x, e.g. Unary('-', x).
+func Unary(op rune, x Expr) Expr { return unary{op, x} }
+
+// Binary returns the binary operator expression x op y, e.g. Binary('+', x, y).
+func Binary(op rune, x, y Expr) Expr { return binary{op, x, y} }
+
+// Call returns the function call expression fn(args...), e.g. Call("sin", x).
+func Call(fn string, args ...Expr) Expr { return call{fn, args} }
+
+// Rewrite returns a copy of e transformed by fn. It visits e in pre-order:
+// when fn returns a replacement and true, the node is replaced and its
+// original children are not visited; otherwise Rewrite descends into the
+// children and rebuilds the node around their rewritten forms. The input
+// expression is not modified.
+//
+// For example, to substitute a sub-expression for a variable:
+//
+//	Rewrite(e, func(n Expr) (Expr, bool) {
+//		if n == Var("F") {
+//			return Binary('+', Var("C"), Lit(32)), true
+//		}
+//		return n, false
+//	})
+func Rewrite(e Expr, fn func(Expr) (Expr, bool)) Expr {
+	if r, ok := fn(e); ok {
+		return r
+	}
+	switch e := e.(type) {
+	case unary:
+		return unary{e.op, Rewrite(e.x, fn)}
+	case binary:
+		return binary{e.op, Rewrite(e.x, fn), Rewrite(e.y, fn)}
+	case call:
+		args := make([]Expr, len(e.args))
+		for i, arg := range e.args {
+			args[i] = Rewrite(arg, fn)
+		}
+		return call{e.fn, args}
+	}
+	return e
+}
@@ -0,0 +1,85 @@
+package expr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomExpr returns a random expression of at most the given depth,
+// covering every node type.
+func randomExpr(r *rand.Rand, depth int) Expr {
+	if depth <= 0 || r.Intn(4) == 0 {
+		switch r.Intn(4) {
+		case 0:
+			return Var([]string{"x", "y", "z", "pi", "F"}[r.Intn(5)])
+		case 1:
+			return literal(r.Intn(100) - 50)
+		case 2:
+			extremes := []float64{0, math.MaxFloat64, math.SmallestNonzeroFloat64, 1e-300, 0x1p-1060}
+			return literal(extremes[r.Intn(len(extremes))])
+		default:
+			return literal(r.NormFloat64() * float64(int64(1)<<uint(r.Intn(80))))
+		}
+	}
+	switch r.Intn(4) {
+	case 0:
+		return unary{rune("+-"[r.Intn(2)]), randomExpr(r, depth-1)}
+	case 1, 2:
+		return binary{rune("+-*/"[r.Intn(4)]), randomExpr(r, depth-1), randomExpr(r, depth-1)}
+	}
+	fns := []string{"pow", "sin", "sqrt"}
+	fn := fns[r.Intn(len(fns))]
+	args := make([]Expr, numParams[fn])
+	for i := range args {
+		args[i] = randomExpr(r, depth-1)
+	}
+	return call{fn, args}
+}
+
+// finite reports whether every literal of e is finite. Infinities and
+// NaN have no infix form, so only such expressions round-trip.
+func finite(e Expr) bool {
+	ok := true
+	Walk(e, func(e Expr) bool {
+		if l, isLit := e.(literal); isLit && (math.IsInf(float64(l), 0) || math.IsNaN(float64(l))) {
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+func checkRoundTrip(t *testing.T, e Expr) {
+	t.Helper()
+	s := Format(e)
+	got, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	if !Equal(got, e) {
+		t.Fatalf("Parse(%q) = %s, want %s", s, ToSExpr(got), ToSExpr(e))
+	}
+}
+
+// TestRoundTrip checks that Parse(Format(e)) reproduces random
+// expressions, and the partially evaluated forms of them.
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	known := Env{"x": 2, "y": 0, "pi": math.Pi}
+	skipped := 0
+	for i := 0; i < 1000; i++ {
+		e := randomExpr(r, 6)
+		checkRoundTrip(t, e)
+
+		partial := PartialEval(e, known)
+		if !finite(partial) {
+			skipped++
+			continue
+		}
+		checkRoundTrip(t, partial)
+	}
+	if skipped == 1000 {
+		t.Fatal("every partially evaluated expression had a non-finite literal")
+	}
+}
@@ -0,0 +1,79 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseRPN parses an expression in reverse Polish (postfix) notation,
+// e.g. "3 4 + 2 *", producing the same tree as the equivalent infix
+// expression. Tokens are separated by white space. The binary operators
+// are + - * /, "neg" negates its operand, the names of known functions
+// consume as many operands as the function takes, numbers and duration
+// literals written as in Parse are literals and any other token is a
+// variable. Variadic functions such as mean take their operand count
+// after a slash, e.g. "1 2 3 mean/3"; a bare variadic name is an error.
+func ParseRPN(input string) (Expr, error) {
+	var stack []Expr
+	pop := func(n int) []Expr {
+		operands := make([]Expr, n)
+		copy(operands, stack[len(stack)-n:])
+		stack = stack[:len(stack)-n]
+		return operands
+	}
+
+	for i, tok := range strings.Fields(input) {
+		if v, ok, err := numberAtom(tok); ok {
+			if err != nil {
+				return nil, fmt.Errorf("token %d (%s): %v", i+1, tok, err)
+			}
+			stack = append(stack, literal(v))
+			continue
+		}
+
+		arity, isFunc := numParams[tok]
+		if name, count, found := strings.Cut(tok, "/"); found {
+			if min, variadic := minParams[name]; variadic {
+				n, err := strconv.Atoi(count)
+				if err != nil || n < min {
+					return nil, fmt.Errorf("token %d (%s): %s takes at least %d operands", i+1, tok, name, min)
+				}
+				tok, arity, isFunc = name, n, true
+			}
+		} else if _, variadic := minParams[tok]; variadic {
+			return nil, fmt.Errorf("token %d (%s): variadic function needs an operand count, e.g. %s/2", i+1, tok, tok)
+		}
+		switch {
+		case len(tok) == 1 && strings.ContainsRune("+-*/", rune(tok[0])):
+			arity = 2
+		case tok == "neg":
+			arity = 1
+		case !isFunc:
+			stack = append(stack, Var(tok))
+			continue
+		}
+		if len(stack) < arity {
+			return nil, fmt.Errorf("token %d (%s): needs %d operands, have %d",
+				i+1, tok, arity, len(stack))
+		}
+
+		operands := pop(arity)
+		switch {
+		case tok == "neg":
+			stack = append(stack, unary{'-', operands[0]})
+		case isFunc:
+			stack = append(stack, call{tok, operands})
+		default:
+			stack = append(stack, binary{rune(tok[0]), operands[0], operands[1]})
+		}
+	}
+
+	switch len(stack) {
+	case 0:
+		return nil, fmt.Errorf("empty expression")
+	case 1:
+		return stack[0], nil
+	}
+	return nil, fmt.Errorf("%d operands left over", len(stack)-1)
+}
@@ -0,0 +1,49 @@
+package expr
+
+import "testing"
+
+func TestParseRPNLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Expr
+	}{
+		{"0x1F", literal(31)},
+		{"0b1010", literal(10)},
+		{"1_000", literal(1000)},
+		{"1.5e-3", literal(1.5e-3)},
+		{"-2", literal(-2)},
+		{"2h", literal(7200)},
+		{"1h30m", literal(5400)},
+		{"inf", Var("inf")},
+		{"NaN", Var("NaN")},
+		{"Infinity", Var("Infinity")},
+		{"2pi", Var("2pi")},
+		{"3 4 +", binary{'+', literal(3), literal(4)}},
+	}
+	for _, test := range tests {
+		e, err := ParseRPN(test.input)
+		if err != nil {
+			t.Errorf("ParseRPN(%q): %v", test.input, err)
+			continue
+		}
+		if !Equal(e, test.want) {
+			t.Errorf("ParseRPN(%q) = %s, want %s", test.input, ToSExpr(e), ToSExpr(test.want))
+		}
+	}
+}
+
+func TestParseRPNVariadic(t *testing.T) {
+	e, err := ParseRPN("1 2 3 mean/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Format(e); got != "mean(1, 2, 3)" {
+		t.Errorf("ParseRPN(\"1 2 3 mean/3\") = %s, want mean(1, 2, 3)", got)
+	}
+
+	for _, input := range []string{"1 2 3 mean", "x median/0", "1 2 percentile/1"} {
+		if e, err := ParseRPN(input); err == nil {
+			t.Errorf("ParseRPN(%q) = %s, want an error", input, Format(e))
+		}
+	}
+}
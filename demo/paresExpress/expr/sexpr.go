@@ -0,0 +1,155 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ToSExpr formats e as an S-expression, e.g. (* (/ 5 9) (- F 32)).
+// Operators and functions are written in prefix position; an operator
+// with a single operand is a unary expression.
+func ToSExpr(e Expr) string {
+	var b strings.Builder
+	writeSExpr(&b, e)
+	return b.String()
+}
+
+func writeSExpr(b *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case Var:
+		b.WriteString(string(e))
+	case literal:
+		b.WriteString(strconv.FormatFloat(float64(e), 'g', -1, 64))
+	case unary:
+		b.WriteByte('(')
+		b.WriteRune(e.op)
+		b.WriteByte(' ')
+		writeSExpr(b, e.x)
+		b.WriteByte(')')
+	case binary:
+		b.WriteByte('(')
+		b.WriteRune(e.op)
+		b.WriteByte(' ')
+		writeSExpr(b, e.x)
+		b.WriteByte(' ')
+		writeSExpr(b, e.y)
+		b.WriteByte(')')
+	case call:
+		b.WriteByte('(')
+		b.WriteString(e.fn)
+		for _, arg := range e.args {
+			b.WriteByte(' ')
+			writeSExpr(b, arg)
+		}
+		b.WriteByte(')')
+	default:
+		b.WriteString(e.String())
+	}
+}
+
+// ParseSExpr parses an expression written in the syntax of ToSExpr.
+// Atoms are numbers, see numberAtom, or else variables: inf and NaN are
+// variable names, so non-finite literals do not survive a round trip, as
+// with Format.
+func ParseSExpr(input string) (Expr, error) {
+	p := &sexprParser{input: input}
+	e, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if tok, pos := p.next(); tok != "" {
+		return nil, fmt.Errorf("at offset %d: unexpected %q after expression", pos, tok)
+	}
+	return e, nil
+}
+
+type sexprParser struct {
+	input string
+	pos   int
+}
+
+// next returns the next token, "(", ")" or an atom, and its offset.
+// It returns "" at the end of input.
+func (p *sexprParser) next() (string, int) {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos == len(p.input) {
+		return "", start
+	}
+	if c := p.input[p.pos]; c == '(' || c == ')' {
+		p.pos++
+		return p.input[start:p.pos], start
+	}
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '(' || c == ')' || unicode.IsSpace(rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos], start
+}
+
+func (p *sexprParser) parse() (Expr, error) {
+	tok, pos := p.next()
+	switch tok {
+	case "":
+		return nil, fmt.Errorf("at offset %d: unexpected end of input", pos)
+	case ")":
+		return nil, fmt.Errorf("at offset %d: unexpected ')'", pos)
+	case "(":
+		return p.parseList(pos)
+	}
+	if v, ok, err := numberAtom(tok); ok {
+		if err != nil {
+			return nil, fmt.Errorf("at offset %d: %v", pos, err)
+		}
+		return literal(v), nil
+	}
+	return Var(tok), nil
+}
+
+// parseList parses the remainder of a list whose '(' is at offset start.
+func (p *sexprParser) parseList(start int) (Expr, error) {
+	head, pos := p.next()
+	switch head {
+	case "":
+		return nil, fmt.Errorf("at offset %d: unclosed '('", start)
+	case "(", ")":
+		return nil, fmt.Errorf("at offset %d: expected operator or function name, got %q", pos, head)
+	}
+
+	var args []Expr
+	for {
+		save := p.pos
+		tok, _ := p.next()
+		if tok == ")" {
+			break
+		}
+		if tok == "" {
+			return nil, fmt.Errorf("at offset %d: unclosed '('", start)
+		}
+		p.pos = save
+		arg, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	if len(head) == 1 && strings.ContainsRune("+-*/", rune(head[0])) {
+		op := rune(head[0])
+		switch {
+		case len(args) == 1 && (op == '+' || op == '-'):
+			return unary{op, args[0]}, nil
+		case len(args) == 2:
+			return binary{op, args[0], args[1]}, nil
+		}
+		return nil, fmt.Errorf("at offset %d: operator %c applied to %d operands", pos, op, len(args))
+	}
+	return call{head, args}, nil
+}
@@ -0,0 +1,31 @@
+package expr
+
+import "testing"
+
+func TestParseSExprAtoms(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Expr
+	}{
+		{"1.5e-3", literal(1.5e-3)},
+		{"-2", literal(-2)},
+		{"0x1F", literal(31)},
+		{"08", literal(8)},
+		{"inf", Var("inf")},
+		{"+Inf", Var("+Inf")},
+		{"NaN", Var("NaN")},
+		{"Infinity", Var("Infinity")},
+		{"2pi", Var("2pi")},
+		{"(- x)", unary{'-', Var("x")}},
+	}
+	for _, test := range tests {
+		e, err := ParseSExpr(test.input)
+		if err != nil {
+			t.Errorf("ParseSExpr(%q): %v", test.input, err)
+			continue
+		}
+		if !Equal(e, test.want) {
+			t.Errorf("ParseSExpr(%q) = %s, want %s", test.input, ToSExpr(e), ToSExpr(test.want))
+		}
+	}
+}
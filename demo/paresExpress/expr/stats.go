@@ -0,0 +1,84 @@
+package expr
+
+import (
+	"math"
+	"sort"
+)
+
+// minParams gives the least number of arguments of the variadic
+// functions, which are not listed in numParams.
+var minParams = map[string]int{
+	"mean": 1, "median": 1, "variance": 1, "stddev": 1,
+	"percentile": 2,
+}
+
+// statsCall evaluates a call to one of the variadic statistics functions:
+//
+//	mean(x...)           arithmetic mean
+//	median(x...)         middle value, or the mean of the two middle values
+//	variance(x...)       population variance
+//	stddev(x...)         population standard deviation
+//	percentile(p, x...)  p-th percentile, 0 <= p <= 100, interpolating
+//	                     linearly between the closest ranks
+//
+// Called without data, as Check and Evaluate reject, they return NaN.
+func statsCall(fn string, args []float64) float64 {
+	switch fn {
+	case "mean":
+		return mean(args)
+	case "median":
+		return percentile(50, args)
+	case "variance":
+		return variance(args)
+	case "stddev":
+		return math.Sqrt(variance(args))
+	case "percentile":
+		if len(args) == 0 {
+			return math.NaN()
+		}
+		return percentile(args[0], args[1:])
+	}
+	panic("unsupported statistics function: " + fn)
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func variance(xs []float64) float64 {
+	m := mean(xs)
+	var sum float64
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	return sum / float64(len(xs))
+}
+
+func percentile(p float64, xs []float64) float64 {
+	if !(0 <= p && p <= 100) || len(xs) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	if lo == len(sorted)-1 {
+		return sorted[lo]
+	}
+	frac := rank - float64(lo)
+	return sorted[lo] + frac*(sorted[lo+1]-sorted[lo])
+}
+
+// knownFunc reports whether name is a builtin function.
+func knownFunc(name string) bool {
+	_, fixed := numParams[name]
+	_, variadic := minParams[name]
+	return fixed || variadic
+}
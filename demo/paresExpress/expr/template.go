@@ -0,0 +1,107 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Template is an expression parsed and compiled once with a declared
+// list of parameters, to be evaluated many times with different
+// arguments. A Template is safe for concurrent use.
+type Template struct {
+	expr   Expr
+	params []Var
+	index  map[Var]int
+	fn     func(...float64) float64
+}
+
+// NewTemplate parses src and compiles it over params. It fails if src
+// does not parse, does not pass Check, or reads a variable that is not
+// one of params.
+func NewTemplate(src string, params ...Var) (*Template, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[Var]bool)
+	if err := e.Check(vars); err != nil {
+		return nil, err
+	}
+
+	index := make(map[Var]int, len(params))
+	for i, p := range params {
+		if _, dup := index[p]; dup {
+			return nil, fmt.Errorf("parameter %s declared twice", p)
+		}
+		index[p] = i
+	}
+	var undeclared []string
+	for _, v := range Vars(e) {
+		if _, ok := index[v]; !ok {
+			undeclared = append(undeclared, string(v))
+		}
+	}
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("undeclared variables in %q: %s",
+			src, strings.Join(undeclared, ", "))
+	}
+
+	return &Template{
+		expr:   e,
+		params: append([]Var(nil), params...),
+		index:  index,
+		fn:     CompileFunc(e, params),
+	}, nil
+}
+
+// MustTemplate is like NewTemplate but panics if the template is invalid.
+// It simplifies initialization of package-level templates.
+func MustTemplate(src string, params ...Var) *Template {
+	t, err := NewTemplate(src, params...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Params returns the declared parameters in positional order.
+func (t *Template) Params() []Var { return append([]Var(nil), t.params...) }
+
+// Expr returns the parsed expression.
+func (t *Template) Expr() Expr { return t.expr }
+
+func (t *Template) String() string { return Format(t.expr) }
+
+// Eval evaluates the template with one argument per parameter, in the
+// order the parameters were declared.
+func (t *Template) Eval(args ...float64) (float64, error) {
+	if len(args) != len(t.params) {
+		return 0, fmt.Errorf("template %s takes %d args, got %d",
+			t, len(t.params), len(args))
+	}
+	return t.fn(args...), nil
+}
+
+// EvalEnv evaluates the template with arguments given by name. Every
+// parameter must be bound in env, and env must not bind anything else.
+func (t *Template) EvalEnv(env Env) (float64, error) {
+	args := make([]float64, len(t.params))
+	for v, value := range env {
+		i, ok := t.index[v]
+		if !ok {
+			return 0, fmt.Errorf("template %s has no parameter %s", t, v)
+		}
+		args[i] = value
+	}
+	if len(env) != len(t.params) {
+		var missing []string
+		for _, p := range t.params {
+			if _, ok := env[p]; !ok {
+				missing = append(missing, string(p))
+			}
+		}
+		return 0, fmt.Errorf("template %s: missing parameters %s",
+			t, strings.Join(missing, ", "))
+	}
+	return t.fn(args...), nil
+}
@@ -0,0 +1,62 @@
+package expr
+
+import (
+	"text/scanner"
+	"time"
+)
+
+// Times and durations are plain numbers: a time is the number of seconds
+// since the Unix epoch, and a duration is a number of seconds. They
+// therefore combine with ordinary arithmetic, e.g. date(2024, 1, 1) + 36h
+// or (now() - t) / 1h for the hours elapsed since t.
+//
+// Duration literals are a number immediately followed by Go duration
+// units, as accepted by time.ParseDuration: 300ms, 30m, 2h, 1h30m.
+
+// timeCall evaluates a call to one of the time functions:
+//
+//	now()          the current time
+//	date(y, m, d)  midnight UTC at the start of the given day
+func timeCall(fn string, args []float64) float64 {
+	switch fn {
+	case "now":
+		return unixSeconds(time.Now())
+	case "date":
+		t := time.Date(int(args[0]), time.Month(int(args[1])), int(args[2]), 0, 0, 0, 0, time.UTC)
+		return unixSeconds(t)
+	}
+	panic("unsupported time function: " + fn)
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// parseDuration reads the units of a duration literal whose number, with
+// the given text and offset, has just been consumed. ok is false if the
+// current token is not adjacent to the number or is not a unit.
+func parseDuration(lex *lexer, number string, offset int) (seconds float64, ok bool) {
+	if lex.token != scanner.Ident || lex.offset != offset+len(number) {
+		return 0, false
+	}
+	d, err := time.ParseDuration(number + lex.text())
+	if err != nil {
+		return 0, false
+	}
+	lex.next() // consume units
+	return d.Seconds(), true
+}
+
+// durationAtom returns the value of a whole token that is a duration
+// literal, such as an operand of ParseRPN. number is the length of the
+// number at its start.
+func durationAtom(tok string, number int) (seconds float64, ok bool) {
+	if number == 0 || number == len(tok) {
+		return 0, false
+	}
+	d, err := time.ParseDuration(tok)
+	if err != nil {
+		return 0, false
+	}
+	return d.Seconds(), true
+}
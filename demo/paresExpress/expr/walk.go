@@ -0,0 +1,68 @@
+package expr
+
+// Walk traverses e in depth-first pre-order. It calls fn for every node;
+// if fn returns false, the children of that node are skipped.
+func Walk(e Expr, fn func(Expr) bool) {
+	if !fn(e) {
+		return
+	}
+	for _, child := range Children(e) {
+		Walk(child, fn)
+	}
+}
+
+// Children returns the direct sub-expressions of e, in evaluation order.
+func Children(e Expr) []Expr {
+	switch e := e.(type) {
+	case unary:
+		return []Expr{e.x}
+	case binary:
+		return []Expr{e.x, e.y}
+	case call:
+		return e.args
+	}
+	return nil
+}
+
+// A Visitor receives the contents of a single node, so that tools can
+// inspect an expression without knowing its concrete node types.
+type Visitor interface {
+	VisitVar(v Var)
+	VisitLiteral(value float64)
+	VisitUnary(op rune, x Expr)
+	VisitBinary(op rune, x, y Expr)
+	VisitCall(fn string, args []Expr)
+}
+
+// Accept calls the method of v matching the node type of e. It does not
+// descend into the children; combine it with Walk to visit a whole tree:
+//
+//	Walk(e, func(n Expr) bool { Accept(n, v); return true })
+func Accept(e Expr, v Visitor) {
+	switch e := e.(type) {
+	case Var:
+		v.VisitVar(e)
+	case literal:
+		v.VisitLiteral(float64(e))
+	case unary:
+		v.VisitUnary(e.op, e.x)
+	case binary:
+		v.VisitBinary(e.op, e.x, e.y)
+	case call:
+		v.VisitCall(e.fn, e.args)
+	}
+}
+
+// Vars returns the distinct variables read by e, in order of first use.
+func Vars(e Expr) []Var {
+	var vars []Var
+	seen := make(map[Var]bool)
+	Walk(e, func(n Expr) bool {
+		if v, ok := n.(Var); ok && !seen[v] {
+			seen[v] = true
+			vars = append(vars, v)
+		}
+		return true
+	})
+	return vars
+}
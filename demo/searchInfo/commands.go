@@ -0,0 +1,547 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/history"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/matchers"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/searchpb"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/server"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"google.golang.org/grpc"
+)
+
+// runFeeds 执行 feeds 子命令
+func runFeeds(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "用法: %s feeds list|validate|add|discover|import|export [参数]\n", os.Args[0])
+		return 2
+	}
+	switch args[0] {
+	case "list":
+		return feedsList(args[1:])
+	case "validate":
+		return feedsValidate(args[1:])
+	case "add":
+		return feedsAdd(args[1:])
+	case "discover":
+		return feedsDiscover(args[1:])
+	case "import":
+		return feedsImport(args[1:])
+	case "export":
+		return feedsExport(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "未知的 feeds 命令: %s\n", args[0])
+	return 2
+}
+
+// feedsFlags 创建 feeds 子命令的参数集，都带有 -feeds 参数
+func feedsFlags(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("feeds "+name, flag.ExitOnError)
+	feedFile := fs.String("feeds", "data/data.json", "数据源文件的路径")
+	return fs, feedFile
+}
+
+// feedsList 以表格列出数据源
+func feedsList(args []string) int {
+	fs, feedFile := feedsFlags("list")
+	fs.Parse(args)
+
+	feeds, err := search.LoadFeeds(*feedFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SITE\tTYPE\tLINK\tTAGS")
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", feed.Name, feed.Type, feed.URI, strings.Join(feed.Tags, ","))
+	}
+	w.Flush()
+	return 0
+}
+
+// feedsValidate 检查数据源文件，打印发现的问题，有错误时返回 1
+func feedsValidate(args []string) int {
+	fs, feedFile := feedsFlags("validate")
+	fs.Parse(args)
+
+	feeds, err := search.LoadFeeds(*feedFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	problems := search.Validate(feeds)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if problems.Err() != nil {
+		return 1
+	}
+	fmt.Printf("%d 个数据源，没有错误\n", len(feeds))
+	return 0
+}
+
+// feedsAdd 在数据源文件末尾添加一个数据源，检查通过后才写回文件
+func feedsAdd(args []string) int {
+	fs, feedFile := feedsFlags("add")
+	site := fs.String("site", "", "数据源名称")
+	feedType := fs.String("type", "rss", "数据源类型")
+	link := fs.String("link", "", "数据源地址")
+	tags := fs.String("tags", "", "逗号分隔的标签")
+	fs.Parse(args)
+
+	feeds, err := search.LoadFeeds(*feedFile)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	feed := &search.Feed{Name: *site, Type: *feedType, URI: *link}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			feed.Tags = append(feed.Tags, tag)
+		}
+	}
+	feeds = append(feeds, feed)
+
+	problems := search.Validate(feeds)
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if problems.Err() != nil {
+		return 1
+	}
+
+	if err := search.SaveFeeds(*feedFile, feeds); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// feedsDiscover 从网页声明的 <link rel="alternate"> 中发现数据源，添加到数据源文件末尾
+// 文件中已有的地址和没有匹配器的类型会被跳过
+func feedsDiscover(args []string) int {
+	fs, feedFile := feedsFlags("discover")
+	tags := fs.String("tags", "", "给发现的数据源加上的标签，逗号分隔")
+	dryRun := fs.Bool("n", false, "只列出发现的数据源，不写入文件")
+	timeout := fs.Duration("timeout", search.DefaultTimeout, "获取网页的时限")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s feeds discover [参数] 网址\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	feeds, err := search.LoadFeeds(*feedFile)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	existing := make(map[string]bool)
+	for _, feed := range feeds {
+		existing[feed.URI] = true
+	}
+	registered := make(map[string]bool)
+	for _, r := range search.Registered() {
+		registered[r.Type] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	discovered, err := matchers.Discover(ctx, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	added := 0
+	for _, feed := range discovered {
+		switch {
+		case existing[feed.URI]:
+			fmt.Fprintf(os.Stderr, "跳过 %s：已在数据源文件中\n", feed.URI)
+			continue
+		case !registered[feed.Type]:
+			fmt.Fprintf(os.Stderr, "跳过 %s：没有 %s 类型的匹配器\n", feed.URI, feed.Type)
+			continue
+		}
+		for _, tag := range strings.Split(*tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				feed.Tags = append(feed.Tags, tag)
+			}
+		}
+		fmt.Printf("%s\t%s\t%s\n", feed.Name, feed.Type, feed.URI)
+		feeds = append(feeds, feed)
+		existing[feed.URI] = true
+		added++
+	}
+	if added == 0 {
+		fmt.Fprintln(os.Stderr, "没有发现新的数据源")
+		return 1
+	}
+	if *dryRun {
+		return 0
+	}
+
+	problems := search.Validate(feeds)
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if problems.Err() != nil {
+		return 1
+	}
+	if err := search.SaveFeeds(*feedFile, feeds); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// feedsImport 把 OPML 文件中的订阅添加到数据源文件末尾，跳过文件中已有的地址
+func feedsImport(args []string) int {
+	fs, feedFile := feedsFlags("import")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s feeds import [参数] 订阅.opml\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	feeds, err := search.LoadFeeds(*feedFile)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	imported, err := search.ImportOPML(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	existing := make(map[string]bool)
+	for _, feed := range feeds {
+		existing[feed.URI] = true
+	}
+	added := 0
+	for _, feed := range imported {
+		if existing[feed.URI] {
+			continue
+		}
+		existing[feed.URI] = true
+		feeds = append(feeds, feed)
+		added++
+	}
+
+	problems := search.Validate(feeds)
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if problems.Err() != nil {
+		return 1
+	}
+	if err := search.SaveFeeds(*feedFile, feeds); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("导入了 %d 个数据源，跳过 %d 个已有的\n", added, len(imported)-added)
+	return 0
+}
+
+// feedsExport 把数据源文件导出为 OPML，默认写到标准输出
+func feedsExport(args []string) int {
+	fs, feedFile := feedsFlags("export")
+	output := fs.String("o", "", "写入的文件，为空时写到标准输出")
+	fs.Parse(args)
+
+	feeds, err := search.LoadFeeds(*feedFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := search.ExportOPML(w, feeds); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// runMatchers 执行 matchers 子命令
+func runMatchers(args []string) int {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintf(os.Stderr, "用法: %s matchers list\n", os.Args[0])
+		return 2
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tPRIORITY\tMATCHER")
+	for _, r := range search.Registered() {
+		fmt.Fprintf(w, "%s\t%d\t%T\n", r.Type, r.Priority, r.Matcher)
+	}
+	w.Flush()
+	return 0
+}
+
+// runHistory 执行 history 子命令
+func runHistory(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "用法: %s history list|show|rerun [参数]\n", os.Args[0])
+		return 2
+	}
+	switch args[0] {
+	case "list":
+		return historyList(args[1:])
+	case "show":
+		return historyShow(args[1:])
+	case "rerun":
+		return historyRerun(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "未知的 history 命令: %s\n", args[0])
+	return 2
+}
+
+// historyFlags 创建 history 子命令的参数集，都带有 -history 参数
+func historyFlags(name, usage string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("history "+name, flag.ExitOnError)
+	path := fs.String("history", history.DefaultPath(), "搜索历史数据库的路径")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s history %s\n", os.Args[0], usage)
+		fs.PrintDefaults()
+	}
+	return fs, path
+}
+
+// openHistory 打开历史数据库，参数 fs.Arg(0) 是搜索的编号
+func openHistory(fs *flag.FlagSet, path string) (*history.Store, int64, bool) {
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		fs.Usage()
+		return nil, 0, false
+	}
+	store, err := history.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 0, false
+	}
+	return store, id, true
+}
+
+// historyList 列出最近的搜索
+func historyList(args []string) int {
+	fs, path := historyFlags("list", "list [参数]")
+	n := fs.Int("n", 20, "列出的搜索数量，0 表示全部")
+	fs.Parse(args)
+
+	store, err := history.Open(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	searches, err := store.List(context.Background(), *n)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tRESULTS\tQUERY")
+	for _, s := range searches {
+		results := strconv.Itoa(s.Results)
+		if s.Err != "" {
+			results += " (有错误)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.ID, s.Time.Local().Format("2006-01-02 15:04:05"), results, s.Query)
+	}
+	w.Flush()
+	return 0
+}
+
+// historyShow 显示一次搜索的结果
+func historyShow(args []string) int {
+	fs, path := historyFlags("show", "show [参数] 编号")
+	fs.Parse(args)
+	store, id, ok := openHistory(fs, *path)
+	if !ok {
+		return 2
+	}
+	defer store.Close()
+
+	s, items, err := store.Get(context.Background(), id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%q  %s  %d 条结果\n", s.Query, s.Time.Local().Format("2006-01-02 15:04:05"), s.Results)
+	if s.Err != "" {
+		fmt.Println(s.Err)
+	}
+	for _, item := range items {
+		fmt.Printf("\n[%s] %s:\n%s\n", item.Feed, item.Field, item.Content)
+		if item.URL != "" {
+			fmt.Println(item.URL)
+		}
+	}
+	return 0
+}
+
+// historyRerun 用过去的搜索词重新搜索，编号之后的参数交给 search 子命令
+func historyRerun(args []string) int {
+	fs, path := historyFlags("rerun", "rerun [参数] 编号 [search 的参数]")
+	fs.Parse(args)
+	store, id, ok := openHistory(fs, *path)
+	if !ok {
+		return 2
+	}
+	s, _, err := store.Get(context.Background(), id)
+	store.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	searchArgs := append([]string{"-history", *path}, fs.Args()[1:]...)
+	return runSearch(append(searchArgs, "--", s.Query))
+}
+
+// runServe 执行 serve 子命令
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "监听的地址")
+	grpcAddr := fs.String("grpc-addr", "", "gRPC 服务监听的地址，为空时不提供 gRPC")
+	feedFile := fs.String("feeds", "data/data.json", "数据源文件的路径或 http(s) 地址")
+	workers := fs.Int("workers", 8, "每次搜索同时搜索的数据源数量，0 表示每个数据源一个 goroutine")
+	timeout := fs.Duration("timeout", search.DefaultTimeout, "单个数据源的搜索时限")
+	cacheTTL := fs.Duration("cache-ttl", 0, "在内存中缓存搜索结果的时间，0 表示不缓存")
+	metrics := fs.Bool("metrics", false, "在 /metrics 上提供 Prometheus 指标")
+	logLevel := fs.String("log-level", "info", "日志级别：debug、info、warn 或 error")
+	logFormat := fs.String("log-format", "text", "日志格式：text 或 json")
+	shutdownTimeout := fs.Duration("shutdown-timeout", defaultShutdownTimeout, "收到 Ctrl-C 或 SIGTERM 后等待进行中的请求结束的时限")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s serve [参数]\n\n接口: GET /search?q=搜索词&format=json|sse|csv|table|plain\n设置 -grpc-addr 时另外提供 gRPC 服务 searchinfo.v1.SearchService\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if err := setupLogging(os.Stderr, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	opts := []search.Option{
+		search.WithFeedsFile(*feedFile),
+		search.WithWorkers(*workers),
+		search.WithTimeout(*timeout),
+	}
+	if *cacheTTL > 0 {
+		opts = append(opts, search.WithCache(search.NewResultCache(*cacheTTL, "")))
+	}
+	var m *search.Metrics
+	if *metrics {
+		m = search.NewMetrics()
+		opts = append(opts, search.WithMetrics(m))
+	}
+
+	// 收到信号后取消进行中的搜索，请求输出已经得到的结果后结束
+	ctx, stop := shutdownContext(*shutdownTimeout)
+	defer stop()
+
+	srv := server.New(opts...)
+	if m != nil {
+		srv.Handle("/metrics", m.Handler())
+	}
+	hs := &http.Server{
+		Addr:        *addr,
+		Handler:     srv,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	var gs *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			slog.Error("serve grpc", "error", err)
+			return 1
+		}
+		g := server.NewGRPC(opts...)
+		g.BaseContext = ctx
+		gs = grpc.NewServer()
+		searchpb.RegisterSearchServiceServer(gs, g)
+		slog.Info("serving grpc", "addr", lis.Addr().String())
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				slog.Error("serve grpc", "error", err)
+			}
+		}()
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("serving", "addr", *addr)
+		errc <- hs.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		slog.Error("serve", "error", err)
+		return 1
+	case <-ctx.Done():
+	}
+
+	// 不再接受新的请求，等待进行中的请求和匹配器结束
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if gs != nil {
+		stopped := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			gs.Stop()
+		}
+	}
+	if err := hs.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("shutdown", "error", err)
+	}
+	if err := search.Wait(shutdownCtx); err != nil {
+		slog.Warn("matchers still running at exit", "timeout", *shutdownTimeout)
+	}
+	return 0
+}
@@ -0,0 +1,235 @@
+// Package history 把每次搜索的搜索词、时间和结果保存在 SQLite 数据库中，
+// 以便查看过去的搜索并重新执行
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// schema 数据库的表结构，打开时自动创建
+const schema = `
+CREATE TABLE IF NOT EXISTS searches (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	query   TEXT     NOT NULL,
+	time    DATETIME NOT NULL,
+	results INTEGER  NOT NULL,
+	error   TEXT     NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS items (
+	search_id INTEGER NOT NULL REFERENCES searches(id) ON DELETE CASCADE,
+	feed      TEXT NOT NULL,
+	type      TEXT NOT NULL,
+	field     TEXT NOT NULL,
+	url       TEXT NOT NULL,
+	content   TEXT NOT NULL,
+	time      DATETIME
+);
+CREATE INDEX IF NOT EXISTS items_search ON items(search_id);
+`
+
+// ErrNotFound 没有指定编号的搜索
+var ErrNotFound = errors.New("search not found")
+
+// Search 一次搜索的记录
+type Search struct {
+	ID    int64
+	Query string
+	Time  time.Time
+	// Results 结果数量
+	Results int
+	// Err 搜索失败的原因，成功时为空
+	Err string
+}
+
+// Item 一次搜索的一个结果
+type Item struct {
+	Feed    string
+	Type    string
+	Field   string
+	URL     string
+	Content string
+	Time    time.Time
+}
+
+// Store 保存搜索历史的数据库
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath 返回用户配置目录下的历史数据库路径
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "searchInfo", "history.db")
+}
+
+// Open 打开 path 处的历史数据库，不存在时创建
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record 保存一次搜索及其结果，runErr 是搜索返回的错误，返回搜索的编号
+func (s *Store) Record(ctx context.Context, query string, at time.Time, results []*search.Result, runErr error) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var msg string
+	if runErr != nil {
+		msg = runErr.Error()
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO searches (query, time, results, error) VALUES (?, ?, ?, ?)`,
+		query, at.UTC(), len(results), msg)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO items (search_id, feed, type, field, url, content, time) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, result := range results {
+		var feed, feedType string
+		if result.Feed != nil {
+			feed, feedType = result.Feed.Name, result.Feed.Type
+		}
+		var t interface{}
+		if !result.Time.IsZero() {
+			t = result.Time.UTC()
+		}
+		if _, err := stmt.ExecContext(ctx, id, feed, feedType, result.Field, result.URL, result.Content, t); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// List 返回最近的 n 次搜索，最新的在前；n 不大于 0 时返回全部
+func (s *Store) List(ctx context.Context, n int) ([]Search, error) {
+	if n <= 0 {
+		n = -1
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, query, time, results, error FROM searches ORDER BY id DESC LIMIT ?`, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var searches []Search
+	for rows.Next() {
+		var r Search
+		if err := rows.Scan(&r.ID, &r.Query, &r.Time, &r.Results, &r.Err); err != nil {
+			return nil, err
+		}
+		searches = append(searches, r)
+	}
+	return searches, rows.Err()
+}
+
+// Get 返回编号为 id 的搜索及其结果，不存在时返回 ErrNotFound
+func (s *Store) Get(ctx context.Context, id int64) (Search, []Item, error) {
+	r := Search{ID: id}
+	err := s.db.QueryRowContext(ctx, `SELECT query, time, results, error FROM searches WHERE id = ?`, id).
+		Scan(&r.Query, &r.Time, &r.Results, &r.Err)
+	if err == sql.ErrNoRows {
+		return r, nil, ErrNotFound
+	}
+	if err != nil {
+		return r, nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT feed, type, field, url, content, time FROM items WHERE search_id = ? ORDER BY rowid`, id)
+	if err != nil {
+		return r, nil, err
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var item Item
+		var t sql.NullTime
+		if err := rows.Scan(&item.Feed, &item.Type, &item.Field, &item.URL, &item.Content, &t); err != nil {
+			return r, nil, err
+		}
+		item.Time = t.Time
+		items = append(items, item)
+	}
+	return r, items, rows.Err()
+}
+
+// Delete 删除编号为 id 的搜索及其结果
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM searches WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Recorder 在显示结果的同时收集它们，搜索结束后用 Save 保存
+type Recorder struct {
+	store   *Store
+	query   string
+	start   time.Time
+	next    search.Displayer
+	mu      sync.Mutex
+	results []*search.Result
+}
+
+// Recorder 返回把结果交给 next 显示并记录下来的 Displayer
+func (s *Store) Recorder(query string, next search.Displayer) *Recorder {
+	return &Recorder{store: s, query: query, start: time.Now(), next: next}
+}
+
+// Display 实现 search.Displayer
+func (r *Recorder) Display(results <-chan *search.Result) error {
+	return search.Tee(r.next, func(result *search.Result) {
+		r.mu.Lock()
+		r.results = append(r.results, result)
+		r.mu.Unlock()
+	}).Display(results)
+}
+
+// Save 保存这次搜索，runErr 是 search.Run 返回的错误
+func (r *Recorder) Save(ctx context.Context, runErr error) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.Record(ctx, r.query, r.start, r.results, runErr)
+}
@@ -1,12 +1,42 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/history"
 	_ "github.com/binarycoder777/mini-go-demo/demo/searchInfo/matchers"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/notify"
 	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+// commands 所有子命令，第一个参数不是子命令名时按 search 处理，兼容原来的用法
+var commands = []command{
+	{"search", "search [参数] 搜索词...          搜索所有数据源", runSearch},
+	{"feeds", "feeds list|validate|add|...     查看、检查、添加、发现、导入和导出数据源", runFeeds},
+	{"matchers", "matchers list                    列出已注册的匹配器", runMatchers},
+	{"history", "history list|show|rerun          查看和重新执行过去的搜索", runHistory},
+	{"serve", "serve [参数]                      以 HTTP 接口提供搜索", runServe},
+}
+
 // init在main之前调用
 func init() {
 	// 日志输出到标准输出
@@ -15,5 +45,300 @@ func init() {
 
 // 程序入口
 func main() {
-	search.Run("president")
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			usage()
+			return
+		}
+	}
+	if err := loadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(args) > 0 {
+		for _, c := range commands {
+			if c.name == args[0] {
+				os.Exit(c.run(args[1:]))
+			}
+		}
+	}
+	os.Exit(runSearch(args))
+}
+
+// loadConfig 读取配置文件，把其中的设置交给各个匹配器
+// 环境变量 SEARCHINFO_CONFIG 指定配置文件的路径，未指定时使用默认路径，
+// 默认路径的文件不存在时不算错误
+func loadConfig() error {
+	path := os.Getenv("SEARCHINFO_CONFIG")
+	if path == "" {
+		if path = search.DefaultConfigPath(); path == "" {
+			return nil
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	config, err := search.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	return config.Apply()
+}
+
+// usage 打印子命令列表
+func usage() {
+	fmt.Fprintf(os.Stderr, "用法: %s <命令> [参数]\n\n命令:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\n使用 %s <命令> -h 查看命令的参数\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "配置文件: %s，可用环境变量 SEARCHINFO_CONFIG 指定\n", search.DefaultConfigPath())
+}
+
+// runSearch 执行 search 子命令
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	feedFile := fs.String("feeds", "data/data.json", "数据源文件的路径或 http(s) 地址")
+	format := fs.String("format", "plain", "输出格式：plain、json、csv 或 table")
+	workers := fs.Int("workers", 8, "同时搜索的数据源数量，0 表示每个数据源一个 goroutine")
+	maxResults := fs.Int("max", 0, "最多显示这么多结果，未指定排序时得到这么多结果后即停止搜索，0 表示不限")
+	timeout := fs.Duration("timeout", search.DefaultTimeout, "单个数据源的搜索时限")
+	progress := fs.Bool("progress", false, "在标准错误上显示搜索进度")
+	interactive := fs.Bool("interactive", false, "在终端中交互式地浏览结果，可以修改搜索词重新搜索")
+	logLevel := fs.String("log-level", "info", "日志级别：debug、info、warn 或 error")
+	logFormat := fs.String("log-format", "text", "日志格式：text 或 json")
+	cacheTTL := fs.Duration("cache-ttl", 0, "缓存搜索结果的时间，在此期间重复的搜索不再访问数据源，0 表示不缓存")
+	cacheDir := fs.String("cache-dir", search.DefaultResultCacheDir(), "保存缓存结果的目录，为空时只缓存在内存中")
+	watch := fs.String("watch", "", "按计划反复搜索，只显示新的结果，如 10m、@hourly 或 \"*/15 * * * *\"")
+	var notifiers []notify.Notifier
+	fs.Func("notify", "把结果推送到 webhook 地址、slack:地址 或 mailto:收件人，可以重复使用", func(spec string) error {
+		n, err := notify.Parse(spec)
+		if err == nil {
+			notifiers = append(notifiers, n)
+		}
+		return err
+	})
+	newOnly := fs.Bool("new-only", false, "只显示上次以同样的搜索词搜索后新出现的结果，见过的结果记录在 -state 文件中")
+	stateFile := fs.String("state", search.DefaultStatePath(), "-new-only 记录见过的结果的文件")
+	historyFile := fs.String("history", history.DefaultPath(), "记录搜索历史的数据库，为空时不记录")
+	metricsAddr := fs.String("metrics-addr", "", "在这个地址的 /metrics 上提供 Prometheus 指标，如 :9090")
+	shutdownTimeout := fs.Duration("shutdown-timeout", defaultShutdownTimeout, "收到 Ctrl-C 或 SIGTERM 后等待搜索收尾的时限")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s search [参数] 搜索词...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	searchTerm := strings.Join(fs.Args(), " ")
+	if searchTerm == "" {
+		fs.Usage()
+		return 2
+	}
+
+	// Ctrl-C 或 SIGTERM 取消正在进行的搜索，输出已经得到的结果后退出
+	ctx, stop := shutdownContext(*shutdownTimeout)
+	defer stop()
+
+	opts := []search.Option{
+		search.WithFeedsFile(*feedFile),
+		search.WithWorkers(*workers),
+		search.WithTimeout(*timeout),
+	}
+	if *maxResults > 0 {
+		opts = append(opts, search.WithMaxResults(*maxResults))
+	}
+
+	if *cacheTTL > 0 {
+		opts = append(opts, search.WithCache(search.NewResultCache(*cacheTTL, *cacheDir)))
+	}
+
+	if *metricsAddr != "" {
+		metrics := search.NewMetrics()
+		opts = append(opts, search.WithMetrics(metrics))
+		if err := serveMetrics(*metricsAddr, metrics); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if *progress && !*interactive {
+		opts = append(opts, search.WithProgress(search.ProgressBar(os.Stderr)))
+	}
+
+	if *interactive {
+		// 日志会打乱界面，交互模式下不输出
+		if err := setupLogging(io.Discard, *logFormat, *logLevel); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if err := runInteractive(ctx, searchTerm, opts...); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	displayer, err := search.NewDisplayer(*format, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	// 增量搜索：只显示上次搜索以来新出现的结果
+	var seen *search.SeenSet
+	if *newOnly {
+		if seen, err = search.LoadSeenSet(*stateFile, searchTerm); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		opts = append(opts, search.WithNewOnly(seen))
+	}
+
+	logOutput := io.Writer(os.Stdout)
+	if _, plain := displayer.(*search.PlainDisplayer); !plain {
+		// 其他格式的输出供其他程序读取，日志改为输出到标准错误
+		logOutput = os.Stderr
+	}
+	if err := setupLogging(logOutput, *logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if len(notifiers) > 0 {
+		displayer = notify.Displayer(displayer, searchTerm, notify.Multi(notifiers...))
+	}
+
+	// 守护模式：按计划反复搜索，直到 Ctrl-C 或 SIGTERM
+	if *watch != "" {
+		schedule, err := search.ParseSchedule(*watch)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		err = search.Watch(ctx, searchTerm, schedule, append(opts, search.WithDisplayer(displayer))...)
+		if ctx.Err() != nil {
+			waitMatchers(*shutdownTimeout)
+		}
+		if err != nil {
+			slog.Error("watch", "error", err)
+			return 1
+		}
+		return 0
+	}
+
+	// 记录搜索历史，数据库打不开时仍然搜索
+	var recorder *history.Recorder
+	if *historyFile != "" {
+		store, err := history.Open(*historyFile)
+		if err != nil {
+			slog.Warn("open history", "path", *historyFile, "error", err)
+		} else {
+			defer store.Close()
+			recorder = store.Recorder(searchTerm, displayer)
+			displayer = recorder
+		}
+	}
+
+	err = search.Run(ctx, searchTerm, append(opts, search.WithDisplayer(displayer))...)
+	// 显示或推送失败时不保存，没有送达的结果下次仍会报告
+	var feedErrs search.Errors
+	if seen != nil && (err == nil || errors.As(err, &feedErrs)) {
+		if err := seen.Save(); err != nil {
+			slog.Warn("save seen results", "path", *stateFile, "error", err)
+		}
+	}
+	if recorder != nil {
+		if _, err := recorder.Save(context.Background(), err); err != nil {
+			slog.Warn("save history", "path", *historyFile, "error", err)
+		}
+	}
+	if ctx.Err() != nil {
+		waitMatchers(*shutdownTimeout)
+	}
+	switch {
+	case errors.As(err, &feedErrs):
+		for _, e := range feedErrs {
+			slog.Error("search feed", "feed", e.Feed.Name, "uri", e.Feed.RedactedURI(), "error", e.Err)
+		}
+		return 1
+	case err != nil:
+		slog.Error("search", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// setupLogging 按级别和格式设置默认的 slog Logger，日志输出到 w
+func setupLogging(w io.Writer, format, level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	logger, err := search.NewLogger(w, format, l)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// serveMetrics 在后台提供 Prometheus 指标，地址无法监听时返回错误
+func serveMetrics(addr string, metrics *search.Metrics) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go http.Serve(ln, mux)
+	return nil
+}
+
+// defaultShutdownTimeout 收到退出信号后等待搜索收尾的默认时限
+const defaultShutdownTimeout = 10 * time.Second
+
+// shutdownContext 返回收到 SIGINT 或 SIGTERM 时取消的 ctx，取消后搜索停止，
+// 已经得到的结果照常输出；之后 timeout 内仍未退出，或再次收到信号时直接结束进程
+// 返回的函数停止监听信号，应在退出前调用
+func shutdownContext(timeout time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-sigs:
+			slog.Info("shutting down", "signal", sig.String(), "timeout", timeout)
+			cancel()
+		case <-done:
+			return
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-sigs:
+			slog.Warn("shutdown interrupted")
+		case <-timer.C:
+			slog.Warn("shutdown timed out", "timeout", timeout)
+		case <-done:
+			return
+		}
+		os.Exit(1)
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
+
+// waitMatchers 退出前在 timeout 内等待仍在执行的匹配器返回
+func waitMatchers(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := search.Wait(ctx); err != nil {
+		slog.Warn("matchers still running at exit", "timeout", timeout)
+	}
 }
@@ -0,0 +1,128 @@
+package matchers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+)
+
+type (
+	// atomLink defines the fields associated with the link tag
+	// in the atom document.
+	atomLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	}
+
+	// atomEntry defines the fields associated with the entry tag
+	// in the atom document.
+	atomEntry struct {
+		ID        string     `xml:"id"`
+		Title     string     `xml:"title"`
+		Summary   string     `xml:"summary"`
+		Content   string     `xml:"content"`
+		Links     []atomLink `xml:"link"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+	}
+
+	// atomDocument defines the fields associated with the atom document,
+	// see RFC 4287.
+	atomDocument struct {
+		XMLName xml.Name    `xml:"feed"`
+		Title   string      `xml:"title"`
+		Entries []atomEntry `xml:"entry"`
+	}
+)
+
+// link returns the address of the entry: its alternate link, which is
+// the default when rel is missing.
+func (e atomEntry) link() string {
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// atomMatcher implements the Matcher interface for Atom feeds.
+type atomMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher atomMatcher
+	search.MustRegister("atom", matcher)
+}
+
+// Search looks at the document for the specified search term.
+func (m atomMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	// Retrieve the data to search.
+	document, err := m.retrieve(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range document.Entries {
+		// Entries must have updated, published is optional.
+		published := parseTime(entry.Published)
+		if published.IsZero() {
+			published = parseTime(entry.Updated)
+		}
+		link := entry.link()
+
+		// Check the title for the search term.
+		if query.Match(entry.Title) {
+			results = append(results, &search.Result{
+				Field:   "Title",
+				Content: entry.Title,
+				URL:     link,
+				Time:    published,
+			})
+		}
+
+		// Check the summary and the content for the search term.
+		if query.Match(entry.Summary) {
+			results = append(results, &search.Result{
+				Field:   "Summary",
+				Content: entry.Summary,
+				URL:     link,
+				Time:    published,
+			})
+		}
+		if query.Match(entry.Content) {
+			results = append(results, &search.Result{
+				Field:   "Content",
+				Content: entry.Content,
+				URL:     link,
+				Time:    published,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// retrieve performs a HTTP Get request for the atom feed and decodes the results.
+func (m atomMatcher) retrieve(ctx context.Context, feed *search.Feed) (*atomDocument, error) {
+	if feed.URI == "" {
+		return nil, errors.New("no atom feed uri provided")
+	}
+
+	resp, err := get(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var document atomDocument
+	err = newXMLDecoder(resp.Body).Decode(&document)
+	return &document, err
+}
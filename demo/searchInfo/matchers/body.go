@@ -0,0 +1,101 @@
+package matchers
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize is the default limit on the size of a response body.
+const DefaultMaxBodySize = search.DefaultMaxBodySize
+
+// SetMaxBodySize sets how many bytes of a response body the HTTP matchers
+// read, after decompression. Longer bodies are truncated with a warning,
+// so a huge or endlessly compressed response cannot exhaust memory. A
+// limit of zero or less disables it. It is the same setting as
+// search.SetMaxBodySize.
+func SetMaxBodySize(n int64) {
+	search.SetMaxBodySize(n)
+}
+
+// decodeBody replaces the body of resp with its decompressed content,
+// limited to the size set by SetMaxBodySize. Bodies in gzip or deflate
+// Content-Encoding are decompressed, and the Content-Encoding and
+// Content-Length headers are removed, as the http.Transport does for the
+// gzip responses it decompresses itself.
+func decodeBody(resp *http.Response) error {
+	body := io.ReadCloser(resp.Body)
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		r, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("gzip body: %w", err)
+		}
+		body = readCloser{r, resp.Body}
+	case "deflate":
+		// deflate is meant to be zlib wrapped, but some servers send raw
+		// deflate data instead.
+		br := bufio.NewReader(resp.Body)
+		var r io.Reader
+		if head, err := br.Peek(2); err == nil && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 && head[0]&0x0f == 8 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return fmt.Errorf("deflate body: %w", err)
+			}
+			r = zr
+		} else {
+			r = flate.NewReader(br)
+		}
+		body = readCloser{r, resp.Body}
+	default:
+		return fmt.Errorf("unsupported Content-Encoding %q", encoding)
+	}
+	if body != resp.Body {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+
+	if n := search.MaxBodySize(); n > 0 {
+		body = &limitedBody{ReadCloser: body, remaining: n, limit: n, url: resp.Request.URL.Redacted()}
+	}
+	resp.Body = body
+	return nil
+}
+
+// limitedBody reads at most limit bytes from a body and logs a warning
+// when the body is longer.
+type limitedBody struct {
+	io.ReadCloser
+	remaining, limit int64
+	url              string
+	truncated        bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		if !b.truncated {
+			b.truncated = true
+			var one [1]byte
+			if n, _ := io.ReadFull(b.ReadCloser, one[:]); n > 0 {
+				slog.Warn("response truncated", "url", b.url, "limit", b.limit)
+			}
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,78 @@
+package matchers
+
+import (
+	"bufio"
+	"encoding/xml"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+)
+
+var (
+	// xmlEncoding matches the encoding of an XML declaration.
+	xmlEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+	// metaCharset matches the charset of an HTML <meta> element, in
+	// either the charset or the http-equiv form.
+	metaCharset = regexp.MustCompile(`(?i)<meta[^>]*\scharset\s*=\s*["']?([A-Za-z0-9._:-]+)`)
+)
+
+// toUTF8 converts a document to UTF-8. The charset is taken from the
+// charset parameter of contentType, or else from the XML declaration or,
+// for HTML, a <meta> element within the first kilobyte. Documents in
+// UTF-8, with an unknown charset or without any declaration are passed
+// through unchanged.
+func toUTF8(r io.Reader, contentType string) io.Reader {
+	br := bufio.NewReader(r)
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	label := params["charset"]
+	if label == "" {
+		head, _ := br.Peek(1024)
+		if m := xmlEncoding.FindSubmatch(head); m != nil {
+			label = string(m[1])
+		} else if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+			if m := metaCharset.FindSubmatch(head); m != nil {
+				label = string(m[1])
+			}
+		}
+	}
+	if label == "" {
+		return br
+	}
+
+	enc, name := charset.Lookup(label)
+	switch {
+	case enc == nil:
+		slog.Debug("unknown charset", "charset", label)
+		return br
+	case name == "utf-8":
+		return br
+	}
+	return transform.NewReader(br, enc.NewDecoder())
+}
+
+// utf8Body replaces the body of resp with its content converted to UTF-8.
+func utf8Body(resp *http.Response) {
+	resp.Body = readCloser{toUTF8(resp.Body, resp.Header.Get("Content-Type")), resp.Body}
+}
+
+// readCloser reads from a Reader and closes a separate Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// newXMLDecoder returns a decoder for a document that has been converted
+// to UTF-8 by toUTF8. The encoding of its XML declaration is ignored, as
+// it names the charset the document was in before the conversion.
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return d
+}
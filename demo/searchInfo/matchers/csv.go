@@ -0,0 +1,81 @@
+package matchers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+// csvMatcher implements the Matcher interface for CSV files.
+type csvMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher csvMatcher
+	search.MustRegister("csv", matcher)
+}
+
+// Search reads the CSV file named by the feed URI, local or remote, and
+// returns every cell containing the search term. The first record is
+// taken as the header naming the columns; data rows are numbered from 1.
+func (m csvMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	if feed.URI == "" {
+		return nil, errors.New("no csv uri provided")
+	}
+	file, err := open(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // rows may have different lengths
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for row := 1; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i, cell := range record {
+			if !query.Match(cell) {
+				continue
+			}
+			column := strconv.Itoa(i + 1)
+			if i < len(header) && header[i] != "" {
+				column = header[i]
+			}
+			results = append(results, &search.Result{
+				Field:   fmt.Sprintf("row %d, column %s", row, column),
+				Content: cell,
+			})
+		}
+	}
+
+	return results, nil
+}
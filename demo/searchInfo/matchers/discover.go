@@ -0,0 +1,153 @@
+package matchers
+
+import (
+	"bufio"
+	"context"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// discoverTypes maps the MIME types feeds are declared with to feed types.
+var discoverTypes = map[string]string{
+	"application/rss+xml":   "rss",
+	"application/x-rss+xml": "rss",
+	"application/atom+xml":  "atom",
+	"application/feed+json": "jsonfeed",
+	"application/json":      "jsonfeed",
+}
+
+// xmlRoot matches the start of an RSS or Atom document.
+var xmlRoot = regexp.MustCompile(`^\s*(<\?xml[^>]*>\s*)?(<!--.*?-->\s*)*<(rss|feed)[\s>]`)
+
+// Discover fetches the page at pageURL and returns the feeds it declares
+// with <link rel="alternate"> elements, typed after their MIME type. When
+// pageURL is itself a feed it is returned on its own. Feeds are named
+// after the title of the link, falling back to the title of the page and
+// then to the host name. Links with an unknown MIME type are ignored; the
+// returned types are not checked against the registered matchers.
+func Discover(ctx context.Context, pageURL string) ([]*search.Feed, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body := bufio.NewReader(resp.Body)
+	if feedType, ok := discoverType(resp.Header.Get("Content-Type")); ok {
+		return []*search.Feed{{Name: base.Hostname(), URI: pageURL, Type: feedType}}, nil
+	}
+	// Feeds are often served as plain XML; look at the root element.
+	head, _ := body.Peek(512)
+	if m := xmlRoot.FindSubmatch(head); m != nil {
+		feedType := "rss"
+		if string(m[3]) == "feed" {
+			feedType = "atom"
+		}
+		return []*search.Feed{{Name: base.Hostname(), URI: pageURL, Type: feedType}}, nil
+	}
+
+	var (
+		feeds []*search.Feed
+		title string
+		seen  = make(map[string]bool)
+	)
+	z := html.NewTokenizer(body)
+	for done := false; !done; {
+		switch z.Next() {
+		case html.ErrorToken:
+			// io.EOF or a broken page; keep whatever was found so far.
+			done = true
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				if title == "" && z.Next() == html.TextToken {
+					title = strings.Join(strings.Fields(string(z.Text())), " ")
+				}
+			case atom.Base:
+				if href := tagAttrs(z, hasAttr)["href"]; href != "" {
+					if u, err := base.Parse(href); err == nil {
+						base = u
+					}
+				}
+			case atom.Link:
+				attrs := tagAttrs(z, hasAttr)
+				if !hasToken(attrs["rel"], "alternate") || attrs["href"] == "" {
+					continue
+				}
+				feedType, ok := discoverType(attrs["type"])
+				if !ok {
+					continue
+				}
+				u, err := base.Parse(attrs["href"])
+				if err != nil || seen[u.String()] {
+					continue
+				}
+				seen[u.String()] = true
+				feeds = append(feeds, &search.Feed{
+					Name: strings.TrimSpace(attrs["title"]),
+					URI:  u.String(),
+					Type: feedType,
+				})
+			case atom.Body:
+				// Feeds are declared in the head; stop before the content.
+				done = true
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, feed := range feeds {
+		if feed.Name == "" {
+			feed.Name = title
+		}
+		if feed.Name == "" {
+			feed.Name = base.Hostname()
+		}
+	}
+	return feeds, nil
+}
+
+// discoverType returns the feed type declared by a MIME type.
+func discoverType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	feedType, ok := discoverTypes[mediaType]
+	return feedType, ok
+}
+
+// tagAttrs returns the attributes of the current tag, keyed by their
+// lower-case names.
+func tagAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, value []byte
+		key, value, hasAttr = z.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(value)
+	}
+	return attrs
+}
+
+// hasToken reports whether the space separated list s contains token,
+// ignoring case.
+func hasToken(s, token string) bool {
+	for _, field := range strings.Fields(s) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package matchers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	// elasticsearchConfig holds the connection settings of an
+	// elasticsearch feed. Feed.URI is the base URL of the cluster.
+	elasticsearchConfig struct {
+		Index    string `json:"index"`
+		Field    string `json:"field"`
+		Size     int    `json:"size"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		APIKey   string `json:"api_key"`
+	}
+
+	// elasticsearchHit defines the fields of a hit we make use of.
+	elasticsearchHit struct {
+		Index     string                     `json:"_index"`
+		ID        string                     `json:"_id"`
+		Source    map[string]json.RawMessage `json:"_source"`
+		Highlight map[string][]string        `json:"highlight"`
+	}
+
+	// elasticsearchResponse defines the fields of a search response we
+	// make use of.
+	elasticsearchResponse struct {
+		Hits struct {
+			Hits []elasticsearchHit `json:"hits"`
+		} `json:"hits"`
+	}
+)
+
+// elasticsearchMatcher implements the Matcher interface for Elasticsearch
+// indices.
+type elasticsearchMatcher struct{}
+
+// elasticsearchDefaults holds the settings from the elasticsearch section
+// of the config file. The config of a feed is decoded on top of them, so
+// credentials shared by every feed need not be repeated in the feeds file.
+var elasticsearchDefaults elasticsearchConfig
+
+// init registers the matcher with the program.
+func init() {
+	var matcher elasticsearchMatcher
+	search.MustRegister("elasticsearch", matcher)
+	search.RegisterConfig("elasticsearch", &elasticsearchDefaults)
+}
+
+// Search runs a match query for the search term against the configured
+// index and returns one result per hit, with the highlighted snippets as
+// the content.
+func (m elasticsearchMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	config := elasticsearchDefaults
+	if len(feed.Config) > 0 {
+		if err := feed.DecodeConfig(&config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Index == "" {
+		return nil, errors.New("elasticsearch config needs an index")
+	}
+	if config.Field == "" {
+		config.Field = "content"
+	}
+	if config.Size == 0 {
+		config.Size = 10
+	}
+
+	response, err := m.retrieve(ctx, feed, config, search.QueryFromContext(ctx, searchTerm))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range response.Hits.Hits {
+		content := strings.Join(hit.Highlight[config.Field], " … ")
+		if content == "" {
+			// No highlight came back, fall back to the field itself.
+			var source string
+			json.Unmarshal(hit.Source[config.Field], &source)
+			content = source
+		}
+		results = append(results, &search.Result{
+			Field:   hit.Index + "/" + hit.ID,
+			Content: content,
+		})
+	}
+
+	return results, nil
+}
+
+// translate turns a query expression into the query DSL: terms become
+// match_phrase queries on field, combined with bool queries. Regular
+// expression terms become regexp queries, which Elasticsearch applies to
+// each indexed token with Lucene syntax, so they are an approximation.
+// The analyzer decides case and word matching of the other terms.
+func (m elasticsearchMatcher) translate(q *search.Query, n search.Node, field string) map[string]interface{} {
+	clauses := func(nodes []search.Node) []interface{} {
+		list := make([]interface{}, len(nodes))
+		for i, c := range nodes {
+			list[i] = m.translate(q, c, field)
+		}
+		return list
+	}
+	var query interface{}
+	switch n := n.(type) {
+	case *search.Term:
+		if n.Regexp != nil {
+			return map[string]interface{}{"regexp": map[string]interface{}{field: map[string]interface{}{
+				"value":            ".*(" + n.Text + ").*",
+				"case_insensitive": !q.CaseSensitive(),
+			}}}
+		}
+		return map[string]interface{}{"match_phrase": map[string]interface{}{field: n.Text}}
+	case *search.And:
+		query = map[string]interface{}{"must": clauses(n.Nodes)}
+	case *search.Or:
+		query = map[string]interface{}{"should": clauses(n.Nodes), "minimum_should_match": 1}
+	case *search.Not:
+		query = map[string]interface{}{"must_not": []interface{}{m.translate(q, n.Node, field)}}
+	default:
+		// An empty query matches nothing.
+		return map[string]interface{}{"match_none": map[string]interface{}{}}
+	}
+	return map[string]interface{}{"bool": query}
+}
+
+// retrieve posts the query, translated into the query DSL, to the _search
+// endpoint of the index and decodes the response.
+func (m elasticsearchMatcher) retrieve(ctx context.Context, feed *search.Feed, config elasticsearchConfig, q *search.Query) (*elasticsearchResponse, error) {
+	if feed.URI == "" {
+		return nil, errors.New("no elasticsearch uri provided")
+	}
+
+	query := map[string]interface{}{
+		"size":  config.Size,
+		"query": m.translate(q, q.Expr(), config.Field),
+		"highlight": map[string]interface{}{
+			"fields": map[string]interface{}{config.Field: map[string]interface{}{}},
+		},
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := strings.TrimSuffix(feed.URI, "/") + "/" + url.PathEscape(config.Index) + "/_search"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case config.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+config.APIKey)
+	case config.Username != "":
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response elasticsearchResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return &response, err
+}
@@ -0,0 +1,141 @@
+package matchers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileMatcher implements the Matcher interface for files on the local
+// file system.
+type fileMatcher struct{}
+
+// fileSettings holds the settings from the file section of the config
+// file.
+var fileSettings struct {
+	// Ignore lists shell patterns, as for filepath.Match, of file and
+	// directory names to skip while walking, such as ".git" or "*.log".
+	Ignore []string `json:"ignore"`
+}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher fileMatcher
+	search.MustRegister("file", matcher)
+	search.RegisterConfig("file", &fileSettings)
+}
+
+// Search treats the feed URI as a directory or a glob pattern, walks every
+// matching file and returns each line containing the search term, in the
+// manner of grep.
+func (m fileMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	paths, err := m.files(feed.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		found, err := m.grep(path, query)
+		if err != nil {
+			slog.Warn("skip file", "path", path, "error", err)
+			continue
+		}
+		results = append(results, found...)
+	}
+
+	return results, nil
+}
+
+// files expands pattern into the regular files it names. Directories,
+// whether given directly or matched by the pattern, are walked recursively,
+// skipping the names matched by the ignore setting.
+func (m fileMatcher) files(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, errors.New("no file pattern provided")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if matches == nil {
+		return nil, fmt.Errorf("no files match %q", pattern)
+	}
+
+	var paths []string
+	for _, match := range matches {
+		err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != match && ignored(d.Name()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// ignored reports whether name matches one of the ignore patterns.
+func ignored(name string) bool {
+	for _, pattern := range fileSettings.Ignore {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// grep returns a result for every line of the file at path that contains
+// the search term. Binary files are skipped.
+func (m fileMatcher) grep(path string, query *search.Query) ([]*search.Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(512); bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var results []*search.Result
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if query.Match(text) {
+			results = append(results, &search.Result{
+				Field:   fmt.Sprintf("%s:%d", path, line),
+				Content: strings.TrimSpace(text),
+			})
+		}
+	}
+	return results, scanner.Err()
+}
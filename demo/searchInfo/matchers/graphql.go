@@ -0,0 +1,127 @@
+package matchers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// graphqlConfig describes how a graphql feed is queried. The term is
+// available to the query both as the $term variable and through a {term}
+// placeholder, which is replaced by the term as a string literal. Results
+// is a JSON path selecting the result items in the response; Field and
+// Content are paths into each item.
+type graphqlConfig struct {
+	Endpoint string            `json:"endpoint"`
+	Query    string            `json:"query"`
+	Results  string            `json:"results"`
+	Field    string            `json:"field"`
+	Content  string            `json:"content"`
+	Headers  map[string]string `json:"headers"`
+}
+
+// graphqlMatcher implements the Matcher interface for GraphQL APIs.
+type graphqlMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher graphqlMatcher
+	search.MustRegister("graphql", matcher)
+}
+
+// Search sends the configured query with the search term and maps the
+// selected items of the response into results.
+func (m graphqlMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	var config graphqlConfig
+	if err := feed.DecodeConfig(&config); err != nil {
+		return nil, err
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = feed.URI
+	}
+	if config.Query == "" || config.Results == "" {
+		return nil, errors.New("graphql config needs a query and a results path")
+	}
+
+	terms := strings.Join(search.QueryFromContext(ctx, searchTerm).Terms(), " ")
+	response, err := m.retrieve(ctx, config, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := jsonPath(response, config.Results)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		field := jsonText(item, config.Field)
+		if config.Field == "" {
+			field = feed.Name
+		}
+		results = append(results, &search.Result{
+			Field:   field,
+			Content: jsonText(item, config.Content),
+		})
+	}
+
+	return results, nil
+}
+
+// retrieve posts the query to the endpoint and decodes the response,
+// failing if the server reported errors.
+func (m graphqlMatcher) retrieve(ctx context.Context, config graphqlConfig, searchTerm string) (interface{}, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("no graphql endpoint provided")
+	}
+
+	literal, _ := json.Marshal(searchTerm)
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     strings.ReplaceAll(config.Query, "{term}", string(literal)),
+		"variables": map[string]string{"term": searchTerm},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", response.Errors[0].Message)
+	}
+
+	// Paths are written against the whole response, as in $.data.search.
+	return map[string]interface{}{"data": response.Data}, nil
+}
@@ -0,0 +1,103 @@
+package matchers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// hnSearchURI is the Algolia Hacker News search endpoint, used when the
+// feed does not name one.
+const hnSearchURI = "https://hn.algolia.com/api/v1/search"
+
+type (
+	// hnHit defines the fields associated with a hit of the Algolia
+	// Hacker News search API.
+	hnHit struct {
+		ObjectID  string `json:"objectID"`
+		Title     string `json:"title"`
+		URL       string `json:"url"`
+		Author    string `json:"author"`
+		Points    int    `json:"points"`
+		CreatedAt string `json:"created_at"`
+	}
+
+	// hnResponse defines the fields of a search response we make use of.
+	hnResponse struct {
+		Hits []hnHit `json:"hits"`
+	}
+)
+
+// hnMatcher implements the Matcher interface for Hacker News stories.
+type hnMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher hnMatcher
+	search.MustRegister("hn", matcher)
+}
+
+// Search queries the Hacker News search API for stories matching the
+// search term. No authentication is needed.
+func (m hnMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	// Retrieve the data to search.
+	response, err := m.retrieve(ctx, feed, search.QueryFromContext(ctx, searchTerm))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range response.Hits {
+		// Ask HN and similar stories have no link of their own.
+		link := hit.URL
+		if link == "" {
+			link = "https://news.ycombinator.com/item?id=" + hit.ObjectID
+		}
+		results = append(results, &search.Result{
+			Field:   fmt.Sprintf("%s (%d points by %s)", hit.Title, hit.Points, hit.Author),
+			Content: link,
+			URL:     link,
+			Time:    parseTime(hit.CreatedAt),
+		})
+	}
+
+	return results, nil
+}
+
+// retrieve performs a HTTP Get request for the search and decodes the results.
+// Algolia requires every word; for AnyTerm all of them are made optional.
+func (m hnMatcher) retrieve(ctx context.Context, feed *search.Feed, q *search.Query) (*hnResponse, error) {
+	base := feed.URI
+	if base == "" {
+		base = hnSearchURI
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	terms := strings.Join(q.Terms(), " ")
+	query.Set("query", terms)
+	if q.Combinator() == search.AnyTerm {
+		query.Set("optionalWords", terms)
+	}
+	query.Set("tags", "story")
+	u.RawQuery = query.Encode()
+
+	resp, err := get(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response hnResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return &response, err
+}
@@ -0,0 +1,182 @@
+package matchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// htmlConfig holds the settings of an html feed.
+type htmlConfig struct {
+	// IgnoreRobots fetches the page even if robots.txt disallows it.
+	IgnoreRobots bool `json:"ignore_robots"`
+}
+
+// htmlMatcher implements the Matcher interface for arbitrary web pages.
+type htmlMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher htmlMatcher
+	search.MustRegister("html", matcher)
+}
+
+// blockElements separate the visible text of a page into paragraphs.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Li: true, atom.Td: true, atom.Th: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Article: true, atom.Section: true,
+	atom.Header: true, atom.Footer: true, atom.Dd: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Caption: true, atom.Br: true, atom.Title: true,
+}
+
+// hiddenElements contain no visible text.
+var hiddenElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Svg: true, atom.Iframe: true,
+}
+
+// Search downloads the page and returns one result per paragraph of
+// visible text containing the search term, with the sentence around the
+// first occurrence as the content.
+func (m htmlMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	// Retrieve the data to search.
+	paragraphs, err := m.retrieve(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, paragraph := range paragraphs {
+		if !query.Match(paragraph) {
+			continue
+		}
+		offset := 0
+		if spans := query.Find(paragraph); len(spans) > 0 {
+			offset = spans[0].Start
+		}
+		results = append(results, &search.Result{
+			Field:   fmt.Sprintf("Paragraph %d", i+1),
+			Content: sentenceAt(paragraph, offset),
+		})
+	}
+
+	return results, nil
+}
+
+// retrieve performs a HTTP Get request for the page and extracts its
+// visible text as a list of paragraphs. Pages disallowed by the robots.txt
+// of the site are not fetched unless the feed sets ignore_robots.
+func (m htmlMatcher) retrieve(ctx context.Context, feed *search.Feed) ([]string, error) {
+	if feed.URI == "" {
+		return nil, errors.New("no html page uri provided")
+	}
+
+	var config htmlConfig
+	if len(feed.Config) > 0 {
+		if err := feed.DecodeConfig(&config); err != nil {
+			return nil, err
+		}
+	}
+	if !config.IgnoreRobots {
+		allowed, err := allowedByRobots(ctx, feed.URI)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, robotsError(feed.URI)
+		}
+	}
+
+	resp, err := get(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return visibleParagraphs(resp.Body)
+}
+
+// visibleParagraphs tokenizes an HTML document and returns the text
+// outside of tags, split into paragraphs at block-level elements, with
+// white space collapsed.
+func visibleParagraphs(r io.Reader) ([]string, error) {
+	var (
+		paragraphs []string
+		current    strings.Builder
+		hidden     int // depth inside elements without visible text
+	)
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		current.Reset()
+	}
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			flush()
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return paragraphs, nil
+		case html.TextToken:
+			if hidden == 0 {
+				current.Write(z.Text())
+				current.WriteByte(' ')
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if hiddenElements[a] && tt == html.StartTagToken {
+				hidden++
+			}
+			if blockElements[a] {
+				flush()
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if hiddenElements[a] && hidden > 0 {
+				hidden--
+			}
+			if blockElements[a] {
+				flush()
+			}
+		}
+	}
+}
+
+// sentenceAt returns the sentence of text that contains the byte offset.
+func sentenceAt(text string, offset int) string {
+	isEnd := func(r rune) bool { return strings.ContainsRune(".!?。！？", r) }
+
+	start := 0
+	for i, r := range text[:offset] {
+		if isEnd(r) {
+			start = i + len(string(r))
+		}
+	}
+	end := len(text)
+	for i, r := range text[offset:] {
+		if isEnd(r) {
+			end = offset + i + len(string(r))
+			break
+		}
+	}
+	return strings.TrimFunc(text[start:end], unicode.IsSpace)
+}
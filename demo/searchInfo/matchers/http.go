@@ -0,0 +1,179 @@
+package matchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// get performs a HTTP Get request for uri and checks that the server
+// answered with 200 OK. The request is abandoned when ctx is cancelled.
+// The caller must close the response body.
+func get(ctx context.Context, uri string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(req)
+}
+
+// do sends req and checks that the server answered with 200 OK. Requests
+// are rate limited per host. Requests to the host of the feed being
+// searched carry the headers and query parameters set in its options,
+// along with the credentials of its auth setting; other hosts, such as
+// the pages a sitemap lists, never see them. Responses to GET requests are cached on
+// disk and revalidated with If-None-Match and If-Modified-Since, except for
+// requests carrying credentials or feed options, which may hold API keys,
+// and responses the server marks private. The body
+// is decompressed and limited in size, see decodeBody, and converted to
+// UTF-8, see toUTF8. The caller must close the response body.
+func do(req *http.Request) (*http.Response, error) {
+	// private requests are not cached, so that the response to one
+	// credential is never served to another.
+	private := false
+	client := search.HTTPClient(req.Context())
+	if feed, ok := search.FromContext(req.Context()); ok && feedHost(feed, req.URL) {
+		before := req.Header.Clone()
+		headers := feed.OptionsWithPrefix("header.")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		params := feed.OptionsWithPrefix("query.")
+		if len(params) > 0 {
+			query := req.URL.Query()
+			for key, value := range params {
+				query.Set(key, value)
+			}
+			req.URL.RawQuery = query.Encode()
+		}
+		if err := feed.Auth.Apply(req); err != nil {
+			return nil, err
+		}
+		private = len(headers) > 0 || len(params) > 0 || feed.Auth != nil
+		client = keepHeadersOnHost(client, feed, changedHeaders(before, req.Header))
+	}
+	private = private || req.URL.User != nil || req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != ""
+
+	// Asking for compression ourselves turns off the transparent gzip
+	// support of the http.Transport, so decodeBody can handle deflate too
+	// and limit the size of the decompressed body.
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+
+	if err := waitHost(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	var cached *cacheEntry
+	if !private {
+		cached = lookupCache(req)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The document has not changed since it was cached.
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		if resp, err = cached.response(req); err != nil {
+			return nil, err
+		}
+		utf8Body(resp)
+		return resp, nil
+	}
+
+	// Check the status code for a 200 so we know we have received a
+	// proper response.
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp.StatusCode)
+	}
+
+	if err := decodeBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if !private {
+		if err := storeCache(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	utf8Body(resp)
+	return resp, nil
+}
+
+// feedHost reports whether u is on the host of the feed URI, which may
+// leave out the scheme, as mastodon instances do. A feed without a URI
+// has no host of its own.
+func feedHost(feed *search.Feed, u *url.URL) bool {
+	uri := feed.URI
+	if !strings.Contains(uri, "://") {
+		uri = "//" + uri
+	}
+	base, err := url.Parse(uri)
+	if err != nil || base.Host == "" {
+		return false
+	}
+	return strings.EqualFold(base.Host, u.Host)
+}
+
+// changedHeaders returns the names of the headers of after that are not
+// in before or have other values there.
+func changedHeaders(before, after http.Header) []string {
+	var names []string
+	for name, values := range after {
+		if strings.Join(before[name], "\n") != strings.Join(values, "\n") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// keepHeadersOnHost returns a copy of client that removes the headers
+// names from redirects leaving the host of the feed. http.Client copies
+// all headers onto redirects and only drops Authorization, Cookie and
+// Www-Authenticate on its own, which would hand API keys and the headers
+// of the feed options to any host the feed redirects to.
+func keepHeadersOnHost(client *http.Client, feed *search.Feed, names []string) *http.Client {
+	if len(names) == 0 {
+		return client
+	}
+	c := *client
+	checkRedirect := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !feedHost(feed, req.URL) {
+			for _, name := range names {
+				req.Header.Del(name)
+			}
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		// The default policy of http.Client.
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &c
+}
+
+// statusError is returned for responses other than 200 OK.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("HTTP response error %d", int(e))
+}
+
+// Transient reports whether the request is worth retrying: server errors
+// and 429 Too Many Requests usually are.
+func (e statusError) Transient() bool {
+	return e >= 500 || e == http.StatusTooManyRequests
+}
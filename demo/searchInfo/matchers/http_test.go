@@ -0,0 +1,51 @@
+package matchers
+
+import (
+	"context"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRedirectDropsCredentials checks that the API key and the option
+// headers of a feed are not sent to another host the feed redirects to.
+func TestRedirectDropsCredentials(t *testing.T) {
+	t.Setenv("TEST_FEED_KEY", "secret")
+
+	var leaked http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Clone()
+		w.Write([]byte("ok"))
+	}))
+	defer other.Close()
+
+	var sent http.Header
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Clone()
+		http.Redirect(w, r, other.URL+"/moved", http.StatusFound)
+	}))
+	defer feedServer.Close()
+
+	feed := &search.Feed{
+		Name:    "redirect",
+		URI:     feedServer.URL,
+		Type:    "rss",
+		Options: map[string]string{"header.X-Custom": "private"},
+		Auth:    &search.Auth{Type: search.AuthAPIKey, TokenEnv: "TEST_FEED_KEY"},
+	}
+	resp, err := get(search.NewContext(context.Background(), feed), feedServer.URL+"/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if sent.Get(search.DefaultAPIKeyHeader) != "secret" || sent.Get("X-Custom") != "private" {
+		t.Errorf("feed host got headers %v, want the API key and X-Custom", sent)
+	}
+	for _, name := range []string{search.DefaultAPIKeyHeader, "X-Custom"} {
+		if v := leaked.Get(name); v != "" {
+			t.Errorf("redirect to another host got %s: %s", name, v)
+		}
+	}
+}
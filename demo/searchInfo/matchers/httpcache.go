@@ -0,0 +1,181 @@
+package matchers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// cacheMu guards cacheDir.
+	cacheMu sync.RWMutex
+
+	// cacheDir is where responses carrying an ETag or Last-Modified header
+	// are kept. An empty cacheDir disables the cache.
+	cacheDir = defaultCacheDir()
+)
+
+// defaultCacheDir returns the cache directory used unless SetHTTPCache is
+// called, inside the user's cache directory.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "searchInfo", "http")
+}
+
+// SetHTTPCache sets the directory in which the HTTP matchers keep
+// responses so that unchanged documents are not downloaded again. An
+// empty dir disables the cache.
+func SetHTTPCache(dir string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cacheDir = dir
+}
+
+// cacheEntry is the metadata of a cached response. The body is stored
+// next to it.
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+
+	path string
+}
+
+// cachePath returns the path, without extension, of the cache files of
+// uri, or "" when the cache is disabled.
+func cachePath(uri string) string {
+	cacheMu.RLock()
+	dir := cacheDir
+	cacheMu.RUnlock()
+	if dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(uri))
+	return filepath.Join(dir, hex.EncodeToString(sum[:]))
+}
+
+// lookupCache returns the cached entry for a GET request and adds the
+// conditional headers to it, or returns nil when nothing is cached.
+func lookupCache(req *http.Request) *cacheEntry {
+	if req.Method != http.MethodGet {
+		return nil
+	}
+	path := cachePath(req.URL.String())
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != req.URL.String() {
+		return nil
+	}
+	entry.path = path
+
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return &entry
+}
+
+// response rebuilds the cached response to req from disk.
+func (e *cacheEntry) response(req *http.Request) (*http.Response, error) {
+	body, err := os.ReadFile(e.path + ".body")
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// storeCache keeps a 200 response to a GET request that carries a
+// validator, unless its Cache-Control forbids shared or any storage. It
+// reads the body and replaces it with an in-memory copy, failing only if
+// the body cannot be read.
+func storeCache(resp *http.Response) error {
+	req := resp.Request
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if req.Method != http.MethodGet || (etag == "" && lastModified == "") || noStore(resp.Header) {
+		return nil
+	}
+	path := cachePath(req.URL.String())
+	if path == "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	entry := cacheEntry{
+		URL:          req.URL.String(),
+		ETag:         etag,
+		LastModified: lastModified,
+		Header:       resp.Header,
+		path:         path,
+	}
+	// A cache that cannot be written only costs a download next time.
+	if err := entry.write(body); err != nil {
+		slog.Warn("write http cache", "url", req.URL.Redacted(), "error", err)
+	}
+	return nil
+}
+
+// noStore reports whether the Cache-Control of a response is private or
+// no-store.
+func noStore(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name = strings.ToLower(name); name == "private" || name == "no-store" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// write saves the entry and the body to disk, readable by the user only.
+func (e *cacheEntry) write(body []byte) error {
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0700); err != nil {
+		return err
+	}
+	// Write the body first so that metadata never points at a missing body.
+	if err := os.WriteFile(e.path+".body", body, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(e.path+".json", meta, 0600)
+}
@@ -0,0 +1,96 @@
+package matchers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+)
+
+type (
+	// jsonFeedItem defines the fields associated with an entry of the
+	// items array in a JSON Feed document.
+	jsonFeedItem struct {
+		ID          string `json:"id"`
+		URL         string `json:"url"`
+		Title       string `json:"title"`
+		ContentText string `json:"content_text"`
+		ContentHTML string `json:"content_html"`
+		Summary     string `json:"summary"`
+		Published   string `json:"date_published"`
+	}
+
+	// jsonFeedDocument defines the fields associated with a JSON Feed
+	// document, see https://www.jsonfeed.org/version/1.1/.
+	jsonFeedDocument struct {
+		Version     string         `json:"version"`
+		Title       string         `json:"title"`
+		HomePageURL string         `json:"home_page_url"`
+		Items       []jsonFeedItem `json:"items"`
+	}
+)
+
+// jsonFeedMatcher implements the Matcher interface for JSON Feed documents.
+type jsonFeedMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher jsonFeedMatcher
+	search.MustRegister("jsonfeed", matcher)
+}
+
+// Search looks at the document for the specified search term.
+func (m jsonFeedMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	// Retrieve the data to search.
+	document, err := m.retrieve(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range document.Items {
+		// Check the title for the search term.
+		if query.Match(item.Title) {
+			results = append(results, &search.Result{
+				Field:   "Title",
+				Content: item.Title,
+				URL:     item.URL,
+				Time:    parseTime(item.Published),
+			})
+		}
+
+		// Check the plain text content for the search term.
+		if query.Match(item.ContentText) {
+			results = append(results, &search.Result{
+				Field:   "Content",
+				Content: item.ContentText,
+				URL:     item.URL,
+				Time:    parseTime(item.Published),
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// retrieve performs a HTTP Get request for the JSON feed and decodes the results.
+func (m jsonFeedMatcher) retrieve(ctx context.Context, feed *search.Feed) (*jsonFeedDocument, error) {
+	if feed.URI == "" {
+		return nil, errors.New("no json feed uri provided")
+	}
+
+	resp, err := get(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var document jsonFeedDocument
+	err = json.NewDecoder(resp.Body).Decode(&document)
+	return &document, err
+}
@@ -0,0 +1,94 @@
+package matchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath evaluates a small subset of JSONPath against a value decoded
+// by encoding/json and returns every value it selects. The supported
+// steps are .name, ['name'], [n] and the wildcards .* and [*]; a leading
+// $ is optional, so "items[*].title" and "$.items[*].title" are the same.
+func jsonPath(v interface{}, path string) ([]interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{v}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch value := value.(type) {
+			case map[string]interface{}:
+				if step == "*" {
+					for _, child := range value {
+						next = append(next, child)
+					}
+				} else if child, ok := value[step]; ok {
+					next = append(next, child)
+				}
+			case []interface{}:
+				if step == "*" {
+					next = append(next, value...)
+				} else if i, err := strconv.Atoi(step); err == nil && i >= 0 && i < len(value) {
+					next = append(next, value[i])
+				}
+			}
+		}
+		values = next
+	}
+	return values, nil
+}
+
+// parseJSONPath splits path into its steps.
+func parseJSONPath(path string) ([]string, error) {
+	var steps []string
+	rest := strings.TrimPrefix(path, "$")
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q: missing ]", path)
+			}
+			steps = append(steps, strings.Trim(rest[1:end], `'"`))
+			rest = rest[end+1:]
+			continue
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("json path %q: empty step", path)
+		}
+		steps = append(steps, rest[:end])
+		rest = rest[end:]
+	}
+	return steps, nil
+}
+
+// jsonText returns the first value path selects in v as text: strings
+// as they are, anything else as JSON. An empty path selects v itself.
+func jsonText(v interface{}, path string) string {
+	if path != "" {
+		values, err := jsonPath(v, path)
+		if err != nil || len(values) == 0 {
+			return ""
+		}
+		v = values[0]
+	}
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,129 @@
+package matchers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// markdownMatcher implements the Matcher interface for Markdown documents.
+type markdownMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher markdownMatcher
+	search.MustRegister("markdown", matcher)
+}
+
+// Search walks the .md files under the directory or glob pattern given by
+// the feed URI and returns every line containing the search term. The
+// field names the file and the heading of the section the line is in.
+func (m markdownMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	paths, err := fileMatcher{}.files(feed.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown":
+		default:
+			continue
+		}
+		found, err := m.search(path, query)
+		if err != nil {
+			slog.Warn("skip file", "path", path, "error", err)
+			continue
+		}
+		results = append(results, found...)
+	}
+
+	return results, nil
+}
+
+// search scans one document, keeping track of the current section. Both
+// ATX (# Heading) and setext (underlined) headings are recognised, except
+// inside fenced code blocks.
+func (m markdownMatcher) search(path string, query *search.Query) ([]*search.Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var results []*search.Result
+	var heading, prev, fence string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		case atxHeading(trimmed) != "":
+			heading = atxHeading(trimmed)
+		case prev != "" && setextUnderline(trimmed):
+			heading = prev
+		}
+		prev = trimmed
+		if fence != "" {
+			prev = ""
+		}
+
+		if query.Match(line) {
+			field := path
+			if heading != "" {
+				field = fmt.Sprintf("%s § %s", path, heading)
+			}
+			results = append(results, &search.Result{
+				Field:   field,
+				Content: trimmed,
+			})
+		}
+	}
+	return results, scanner.Err()
+}
+
+// atxHeading returns the text of an ATX heading line, or "" if line is not
+// one.
+func atxHeading(line string) string {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return ""
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return ""
+	}
+	// Drop an optional closing sequence of #s.
+	rest = strings.TrimSpace(rest)
+	if trimmed := strings.TrimRight(rest, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		rest = strings.TrimSpace(trimmed)
+	}
+	return rest
+}
+
+// setextUnderline reports whether line underlines the previous line as a
+// setext heading.
+func setextUnderline(line string) bool {
+	return line != "" && (strings.Trim(line, "=") == "" || strings.Trim(line, "-") == "")
+}
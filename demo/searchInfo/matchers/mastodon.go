@@ -0,0 +1,129 @@
+package matchers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// mastodonConfig holds the instance a mastodon feed searches and the
+	// access token to search it with. Without a token most instances only
+	// return statuses from hashtag searches.
+	mastodonConfig struct {
+		Instance string `json:"instance"`
+		Token    string `json:"token"`
+		Limit    int    `json:"limit"`
+	}
+
+	// mastodonStatus defines the fields associated with a status.
+	mastodonStatus struct {
+		ID        string `json:"id"`
+		URL       string `json:"url"`
+		CreatedAt string `json:"created_at"`
+		Content   string `json:"content"`
+		Account   struct {
+			Acct string `json:"acct"`
+		} `json:"account"`
+	}
+
+	// mastodonResponse defines the fields of a search response we make
+	// use of.
+	mastodonResponse struct {
+		Statuses []mastodonStatus `json:"statuses"`
+	}
+)
+
+// mastodonMatcher implements the Matcher interface for Mastodon instances.
+type mastodonMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher mastodonMatcher
+	search.MustRegister("mastodon", matcher)
+}
+
+// Search uses the search API of the configured instance and returns the
+// matching statuses, with their HTML content reduced to plain text.
+func (m mastodonMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	var config mastodonConfig
+	if len(feed.Config) > 0 {
+		if err := feed.DecodeConfig(&config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Instance == "" {
+		config.Instance = feed.URI
+	}
+	if config.Limit == 0 {
+		config.Limit = 20
+	}
+
+	terms := strings.Join(search.QueryFromContext(ctx, searchTerm).Terms(), " ")
+	response, err := m.retrieve(ctx, config, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range response.Statuses {
+		paragraphs, err := visibleParagraphs(strings.NewReader(status.Content))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &search.Result{
+			Field:   "@" + status.Account.Acct + " " + status.URL,
+			Content: strings.Join(paragraphs, "\n"),
+			URL:     status.URL,
+			Time:    parseTime(status.CreatedAt),
+		})
+	}
+
+	return results, nil
+}
+
+// retrieve performs a HTTP Get request for the status search and decodes
+// the results.
+func (m mastodonMatcher) retrieve(ctx context.Context, config mastodonConfig, searchTerm string) (*mastodonResponse, error) {
+	if config.Instance == "" {
+		return nil, errors.New("no mastodon instance provided")
+	}
+	base := config.Instance
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+
+	query := url.Values{
+		"q":       {searchTerm},
+		"type":    {"statuses"},
+		"resolve": {"false"},
+		"limit":   {strconv.Itoa(config.Limit)},
+	}
+	uri := strings.TrimSuffix(base, "/") + "/api/v2/search?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+config.Token)
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response mastodonResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return &response, err
+}
@@ -0,0 +1,145 @@
+package matchers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rsc.io/pdf"
+)
+
+// maxPDFSize bounds how much of a document is read into memory.
+const maxPDFSize = 64 << 20
+
+// pdfMatcher implements the Matcher interface for PDF documents.
+type pdfMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher pdfMatcher
+	search.MustRegister("pdf", matcher)
+}
+
+// Search extracts the text of the documents referenced by the feed URI and
+// returns every line containing the search term, with the page number in
+// the field. The URI is either an http(s) URL of one document or a local
+// path, directory or glob pattern naming a collection of them.
+func (m pdfMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	paths := []string{feed.URI}
+	if !isRemote(feed.URI) {
+		files, err := fileMatcher{}.files(feed.URI)
+		if err != nil {
+			return nil, err
+		}
+		paths = paths[:0]
+		for _, path := range files {
+			if strings.EqualFold(filepath.Ext(path), ".pdf") {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		found, err := m.search(ctx, path, query)
+		if err != nil {
+			slog.Warn("skip file", "path", path, "error", err)
+			continue
+		}
+		results = append(results, found...)
+	}
+
+	return results, nil
+}
+
+// search reads one document and searches it page by page.
+func (m pdfMatcher) search(ctx context.Context, uri string, query *search.Query) ([]*search.Result, error) {
+	file, err := open(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxPDFSize))
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*search.Result
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		lines, err := pageLines(page)
+		if err != nil {
+			return results, fmt.Errorf("page %d: %v", i, err)
+		}
+		for _, line := range lines {
+			if query.Match(line) {
+				results = append(results, &search.Result{
+					Field:   fmt.Sprintf("%s, page %d", uri, i),
+					Content: line,
+				})
+			}
+		}
+	}
+	return results, nil
+}
+
+// pageLines reassembles the text drawn on page into lines. The PDF only
+// positions runs of glyphs, so runs on the same baseline are joined from
+// left to right, with a space where they leave a gap.
+func pageLines(page pdf.Page) (lines []string, err error) {
+	// The pdf package panics on malformed content streams.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	texts := page.Content().Text
+	sort.SliceStable(texts, func(i, j int) bool {
+		if yi, yj := math.Round(texts[i].Y), math.Round(texts[j].Y); yi != yj {
+			return yi > yj
+		}
+		return texts[i].X < texts[j].X
+	})
+
+	var line strings.Builder
+	for i, t := range texts {
+		if i > 0 {
+			prev := texts[i-1]
+			switch {
+			case math.Round(t.Y) != math.Round(prev.Y):
+				lines = append(lines, strings.TrimSpace(line.String()))
+				line.Reset()
+			case t.X-(prev.X+prev.W) > t.FontSize*0.2:
+				line.WriteByte(' ')
+			}
+		}
+		line.WriteString(t.S)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, strings.TrimSpace(line.String()))
+	}
+	return lines, nil
+}
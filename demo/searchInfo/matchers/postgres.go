@@ -0,0 +1,122 @@
+package matchers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresConfig holds the connection settings and the columns searched
+// by a postgres feed. Language is the text search configuration and
+// defaults to english.
+type postgresConfig struct {
+	tableConfig
+	DSN      string `json:"dsn"`
+	Language string `json:"language"`
+}
+
+// postgresMatcher implements the Matcher interface for PostgreSQL tables,
+// using the built-in full-text search.
+type postgresMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher postgresMatcher
+	search.MustRegister("postgres", matcher)
+}
+
+// Search connects with the configured DSN and returns every row of the
+// configured table whose columns match the search term as a full-text
+// query. Rows are identified by the id column unless the config names
+// another key.
+func (m postgresMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	var config postgresConfig
+	if err := feed.DecodeConfig(&config); err != nil {
+		return nil, err
+	}
+	if err := config.check("id"); err != nil {
+		return nil, fmt.Errorf("postgres %v", err)
+	}
+	if config.DSN == "" {
+		// Fall back to the feed URI, a postgres:// URL is a valid DSN.
+		config.DSN = feed.URI
+	}
+	if config.DSN == "" {
+		return nil, errors.New("no postgres dsn provided")
+	}
+	if config.Language == "" {
+		config.Language = "english"
+	}
+
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query, args := m.query(config, search.QueryFromContext(ctx, searchTerm))
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return rowResults(rows, config.tableConfig)
+}
+
+// query builds the full-text SELECT statement for config and its
+// arguments: the text search configuration as $1 and the terms after it.
+// The query expression is translated into the WHERE clause, each term
+// matching the document through plainto_tsquery, which needs all the
+// words of the term in any order and position, or the ~ operator for
+// regular expression terms. Full-text search always ignores case and
+// matches whole words; the case and whole word settings of the query only
+// apply to regular expression terms.
+func (m postgresMatcher) query(config postgresConfig, q *search.Query) (string, []interface{}) {
+	columns := make([]string, len(config.Columns))
+	for i, column := range config.Columns {
+		columns[i] = quoteIdent(column)
+	}
+	list := strings.Join(columns, ", ")
+
+	document := fmt.Sprintf("concat_ws(' ', %s)", list)
+	args := []interface{}{config.Language}
+	var where func(n search.Node) string
+	where = func(n search.Node) string {
+		switch n := n.(type) {
+		case *search.Term:
+			if n.Regexp != nil {
+				pattern, operator := "(?:"+n.Text+")", "~"
+				if q.WholeWord() {
+					pattern = `\y` + pattern + `\y`
+				}
+				if !q.CaseSensitive() {
+					operator = "~*"
+				}
+				args = append(args, pattern)
+				return fmt.Sprintf("%s %s $%d", document, operator, len(args))
+			}
+			args = append(args, n.Text)
+			return fmt.Sprintf("to_tsvector($1::regconfig, %s) @@ plainto_tsquery($1::regconfig, $%d)", document, len(args))
+		case *search.And:
+			return "(" + joinWhere(n.Nodes, where, " AND ") + ")"
+		case *search.Or:
+			return "(" + joinWhere(n.Nodes, where, " OR ") + ")"
+		case *search.Not:
+			return "NOT " + where(n.Node)
+		}
+		return "false" // an empty query matches nothing
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s",
+		quoteIdent(config.Key), list, quoteIdent(config.Table), where(q.Expr()))
+	return query, args
+}
@@ -0,0 +1,64 @@
+package matchers
+
+import (
+	"context"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	// limitMu guards the rate limit settings and the limiters.
+	limitMu sync.Mutex
+
+	// defaultLimit and defaultBurst apply to feeds without a rate_limit.
+	defaultLimit = rate.Limit(5)
+	defaultBurst = 5
+
+	// limiters holds the limiter of every host requested so far.
+	limiters = make(map[string]*rate.Limiter)
+)
+
+// SetRateLimit sets how many requests per second the HTTP matchers send to
+// any one host, and how many they may send at once. A feed may override
+// the rate for its host with rate_limit. All feeds on a host share one
+// limiter, which runs at the strictest rate any of them asked for. A rate
+// of zero or less disables the limit.
+func SetRateLimit(perSecond float64, burst int) {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+
+	defaultLimit = rate.Limit(perSecond)
+	if perSecond <= 0 {
+		defaultLimit = rate.Inf
+	}
+	defaultBurst = burst
+	limiters = make(map[string]*rate.Limiter)
+}
+
+// waitHost blocks until a request may be sent to host, honouring the rate
+// limit of the feed being searched with ctx, if any.
+func waitHost(ctx context.Context, host string) error {
+	limitMu.Lock()
+	limit := defaultLimit
+	if feed, ok := search.FromContext(ctx); ok && feed.RateLimit > 0 {
+		limit = rate.Limit(feed.RateLimit)
+	}
+	limiter, exists := limiters[host]
+	if !exists {
+		burst := defaultBurst
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(limit, burst)
+		limiters[host] = limiter
+	} else if limit < limiter.Limit() {
+		// A stricter feed on the same host slows down every feed on it,
+		// otherwise their requests together would exceed its limit.
+		limiter.SetLimit(limit)
+	}
+	limitMu.Unlock()
+
+	return limiter.Wait(ctx)
+}
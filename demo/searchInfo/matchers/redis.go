@@ -0,0 +1,292 @@
+package matchers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisConfig selects what a redis feed searches. With Set or Hash the
+// members of that key are searched, otherwise the string values of every
+// key matching Pattern, which defaults to all keys.
+type redisConfig struct {
+	Pattern string `json:"pattern"`
+	Set     string `json:"set"`
+	Hash    string `json:"hash"`
+}
+
+// redisMatcher implements the Matcher interface for a Redis keyspace.
+type redisMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher redisMatcher
+	search.MustRegister("redis", matcher)
+}
+
+// Search connects to the server named by the feed URI, a
+// redis://[user:password@]host[:port][/db] URL, and returns the entries
+// whose values contain the search term.
+func (m redisMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	var config redisConfig
+	if len(feed.Config) > 0 {
+		if err := feed.DecodeConfig(&config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Pattern == "" {
+		config.Pattern = "*"
+	}
+
+	conn, err := dialRedis(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	match := func(field, value string) {
+		if query.Match(value) {
+			results = append(results, &search.Result{Field: field, Content: value})
+		}
+	}
+
+	switch {
+	case config.Set != "":
+		members, err := conn.strings("SMEMBERS", config.Set)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			match(config.Set, member)
+		}
+
+	case config.Hash != "":
+		pairs, err := conn.strings("HGETALL", config.Hash)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			match(config.Hash+" "+pairs[i], pairs[i+1])
+		}
+
+	default:
+		keys, err := conn.scan(config.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			reply, err := conn.do("GET", key)
+			if err != nil {
+				// Keys holding other types answer WRONGTYPE, skip them.
+				if _, ok := err.(redisError); ok {
+					continue
+				}
+				return nil, err
+			}
+			if value, ok := reply.(string); ok {
+				match(key, value)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// redisError is an error reply sent by the server.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// redisConn is a minimal client speaking the RESP protocol, just enough
+// for the read-only commands the matcher needs.
+type redisConn struct {
+	net.Conn
+	r    *bufio.Reader
+	ctx  context.Context
+	stop func() bool
+}
+
+// dialRedis connects to the server named by uri, authenticating and
+// selecting the database given in it.
+func dialRedis(ctx context.Context, uri string) (*redisConn, error) {
+	if uri == "" {
+		return nil, errors.New("no redis uri provided")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported redis uri scheme %q", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: conn, r: bufio.NewReader(conn), ctx: ctx}
+
+	// Unblock any pending read or write once ctx is cancelled.
+	c.stop = context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	if password, ok := u.User.Password(); ok {
+		args := []string{"AUTH", password}
+		if user := u.User.Username(); user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if _, err := c.do(args...); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if _, err := c.do("SELECT", db); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Close closes the connection.
+func (c *redisConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// do sends a command and reads its reply. Once the context of the
+// connection is cancelled it fails with the context's error.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := io.WriteString(c.Conn, b.String())
+	var reply interface{}
+	if err == nil {
+		reply, err = c.reply()
+	}
+	if _, ok := err.(redisError); err != nil && !ok && c.ctx.Err() != nil {
+		return nil, c.ctx.Err()
+	}
+	return reply, err
+}
+
+// strings sends a command whose reply is an array of bulk strings.
+func (c *redisConn) strings(args ...string) ([]string, error) {
+	reply, err := c.do(args...)
+	if err != nil {
+		return nil, err
+	}
+	return redisStrings(reply)
+}
+
+// scan collects every key matching pattern using SCAN, so the server is
+// never blocked the way KEYS would block it.
+func (c *redisConn) scan(pattern string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := c.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, errors.New("unexpected SCAN reply")
+		}
+		batch, err := redisStrings(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor, ok = parts[0].(string); !ok || cursor == "0" {
+			return keys, nil
+		}
+	}
+}
+
+// reply reads one RESP value: a string, an int64, nil, an []interface{}
+// or a redisError.
+func (c *redisConn) reply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			// An error inside an array is a value, not a failed read.
+			v, err := c.reply()
+			if _, ok := err.(redisError); err != nil && !ok {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply %q", line)
+}
+
+// redisStrings converts an array reply into its strings.
+func redisStrings(reply interface{}) ([]string, error) {
+	values, ok := reply.([]interface{})
+	if !ok {
+		return nil, errors.New("redis reply is not an array")
+	}
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			s = append(s, str)
+		}
+	}
+	return s, nil
+}
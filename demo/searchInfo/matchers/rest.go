@@ -0,0 +1,123 @@
+package matchers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// restConfig describes a JSON API searched by a rest feed. The {term}
+// placeholder in URL is replaced by the query-escaped search term, and in
+// Body by the term escaped for a JSON string. Results is a JSON path
+// selecting the result items, the top-level array or object by default;
+// Field and Content are paths into each item.
+type restConfig struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	Results string            `json:"results"`
+	Field   string            `json:"field"`
+	Content string            `json:"content"`
+}
+
+// restMatcher implements the Matcher interface for JSON APIs described
+// entirely by the feed configuration.
+type restMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher restMatcher
+	search.MustRegister("rest", matcher)
+}
+
+// Search calls the configured API for the search term and maps the
+// selected items of the response into results.
+func (m restMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	var config restConfig
+	if err := feed.DecodeConfig(&config); err != nil {
+		return nil, err
+	}
+	if config.URL == "" {
+		config.URL = feed.URI
+	}
+
+	terms := strings.Join(search.QueryFromContext(ctx, searchTerm).Terms(), " ")
+	response, err := m.retrieve(ctx, config, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []interface{}{response}
+	if config.Results != "" {
+		if items, err = jsonPath(response, config.Results); err != nil {
+			return nil, err
+		}
+	} else if array, ok := response.([]interface{}); ok {
+		items = array
+	}
+
+	for _, item := range items {
+		field := jsonText(item, config.Field)
+		if config.Field == "" {
+			field = feed.Name
+		}
+		results = append(results, &search.Result{
+			Field:   field,
+			Content: jsonText(item, config.Content),
+		})
+	}
+
+	return results, nil
+}
+
+// retrieve sends the configured request and decodes the JSON response.
+func (m restMatcher) retrieve(ctx context.Context, config restConfig, searchTerm string) (interface{}, error) {
+	if config.URL == "" {
+		return nil, errors.New("no rest url provided")
+	}
+	method := strings.ToUpper(config.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if config.Body != "" {
+		literal, _ := json.Marshal(searchTerm)
+		escaped := strings.Trim(string(literal), `"`)
+		body = strings.NewReader(strings.ReplaceAll(config.Body, "{term}", escaped))
+	}
+
+	uri := strings.ReplaceAll(config.URL, "{term}", url.QueryEscape(searchTerm))
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response interface{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
@@ -0,0 +1,205 @@
+package matchers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsAgent is the name the matchers go by in robots.txt files.
+const robotsAgent = "searchinfo"
+
+// robotsTTL is how long a robots.txt file is kept before it is fetched
+// again.
+const robotsTTL = 24 * time.Hour
+
+var (
+	// robotsMu guards robotsCache.
+	robotsMu sync.Mutex
+
+	// robotsCache holds the robots.txt rules of every site checked so far,
+	// keyed by scheme and host.
+	robotsCache = make(map[string]*robotsRules)
+)
+
+// robotsRule allows or disallows the paths matching a pattern.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules are the rules of a robots.txt file that apply to
+// robotsAgent.
+type robotsRules struct {
+	rules   []robotsRule
+	expires time.Time
+}
+
+// allowedByRobots reports whether the robots.txt file of the site of uri
+// lets robotsAgent fetch uri. The file is fetched once per site and kept
+// for robotsTTL. A site without a robots.txt file allows everything.
+func allowedByRobots(ctx context.Context, uri string) (bool, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return true, nil
+	}
+	site := u.Scheme + "://" + u.Host
+
+	robotsMu.Lock()
+	rules, ok := robotsCache[site]
+	robotsMu.Unlock()
+	if !ok || time.Now().After(rules.expires) {
+		if rules, err = fetchRobots(ctx, site); err != nil {
+			return false, err
+		}
+		robotsMu.Lock()
+		robotsCache[site] = rules
+		robotsMu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allowed(path), nil
+}
+
+// fetchRobots downloads and parses the robots.txt file of site. A file
+// that does not exist, or is otherwise refused with a 4xx status, allows
+// everything; other failures are returned, as the site may not want to be
+// crawled at all.
+func fetchRobots(ctx context.Context, site string) (*robotsRules, error) {
+	resp, err := get(ctx, site+"/robots.txt")
+	var status statusError
+	switch {
+	case errors.As(err, &status) && status >= 400 && status < 500:
+		return &robotsRules{expires: time.Now().Add(robotsTTL)}, nil
+	case err != nil:
+		return nil, fmt.Errorf("robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rules, err := parseRobots(resp.Body, robotsAgent)
+	if err != nil {
+		return nil, fmt.Errorf("robots.txt: %w", err)
+	}
+	rules.expires = time.Now().Add(robotsTTL)
+	return rules, nil
+}
+
+// parseRobots reads a robots.txt file and returns the rules of the groups
+// naming agent, or of the * groups when none do, see RFC 9309.
+func parseRobots(r io.Reader, agent string) (*robotsRules, error) {
+	var (
+		named, wildcard []robotsRule
+		foundNamed      bool
+
+		// The group being read: its user agents and whether its rules
+		// have started, which ends the list of user agents.
+		forNamed, forAny, inRules bool
+	)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				forNamed, forAny, inRules = false, false, false
+			}
+			switch name := strings.ToLower(value); {
+			case name == "*":
+				forAny = true
+			case name != "" && (strings.Contains(agent, name) || strings.Contains(name, agent)):
+				forNamed, foundNamed = true, true
+			}
+		case "allow", "disallow":
+			inRules = true
+			// An empty disallow allows everything, which needs no rule.
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{pattern: value, allow: key == "allow"}
+			if forNamed {
+				named = append(named, rule)
+			}
+			if forAny {
+				wildcard = append(wildcard, rule)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if foundNamed {
+		return &robotsRules{rules: named}, nil
+	}
+	return &robotsRules{rules: wildcard}, nil
+}
+
+// allowed reports whether path may be fetched: the rule with the longest
+// matching pattern decides, allow winning a tie, and a path no rule
+// matches is allowed.
+func (r *robotsRules) allowed(path string) bool {
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allow, longest = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// robotsMatch reports whether path matches a robots.txt pattern, a path
+// prefix in which * matches any characters and a trailing $ anchors the
+// end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		// The last part of an anchored pattern must end the path.
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// robotsError is returned for pages disallowed by robots.txt.
+type robotsError string
+
+func (e robotsError) Error() string {
+	return fmt.Sprintf("%s is disallowed by robots.txt", string(e))
+}
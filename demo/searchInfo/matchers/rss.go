@@ -1,13 +1,11 @@
 package matchers
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
-	"fmt"
 	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
-	"log"
-	"net/http"
-	"regexp"
+	"log/slog"
 )
 
 type (
@@ -62,47 +60,40 @@ type rssMatcher struct{}
 // init registers the matcher with the program.
 func init() {
 	var matcher rssMatcher
-	search.Register("rss", matcher)
+	search.MustRegister("rss", matcher)
 }
 
 // Search looks at the document for the specified search term.
-func (m rssMatcher) Search(feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+func (m rssMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
 	var results []*search.Result
 
-	log.Printf("Search Feed Type[%s] Site[%s] For URI[%s]\n", feed.Type, feed.Name, feed.URI)
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
 
 	// Retrieve the data to search.
-	document, err := m.retrieve(feed)
+	document, err := m.retrieve(ctx, feed)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, channelItem := range document.Channel.Item {
 		// Check the title for the search term.
-		matched, err := regexp.MatchString(searchTerm, channelItem.Title)
-		if err != nil {
-			return nil, err
-		}
-
-		// If we found a match save the result.
-		if matched {
+		if query.Match(channelItem.Title) {
 			results = append(results, &search.Result{
 				Field:   "Title",
 				Content: channelItem.Title,
+				URL:     channelItem.Link,
+				Time:    parseTime(channelItem.PubDate),
 			})
 		}
 
 		// Check the description for the search term.
-		matched, err = regexp.MatchString(searchTerm, channelItem.Description)
-		if err != nil {
-			return nil, err
-		}
-
-		// If we found a match save the result.
-		if matched {
+		if query.Match(channelItem.Description) {
 			results = append(results, &search.Result{
 				Field:   "Description",
 				Content: channelItem.Description,
+				URL:     channelItem.Link,
+				Time:    parseTime(channelItem.PubDate),
 			})
 		}
 	}
@@ -111,13 +102,13 @@ func (m rssMatcher) Search(feed *search.Feed, searchTerm string) ([]*search.Resu
 }
 
 // retrieve performs a HTTP Get request for the rss feed and decodes the results.
-func (m rssMatcher) retrieve(feed *search.Feed) (*rssDocument, error) {
+func (m rssMatcher) retrieve(ctx context.Context, feed *search.Feed) (*rssDocument, error) {
 	if feed.URI == "" {
-		return nil, errors.New("No rss feed uri provided")
+		return nil, errors.New("no rss feed uri provided")
 	}
 
 	// Retrieve the rss feed document from the web.
-	resp, err := http.Get(feed.URI)
+	resp, err := get(ctx, feed.URI)
 	if err != nil {
 		return nil, err
 	}
@@ -125,15 +116,9 @@ func (m rssMatcher) retrieve(feed *search.Feed) (*rssDocument, error) {
 	// Close the response once we return from the function.
 	defer resp.Body.Close()
 
-	// Check the status code for a 200 so we know we have received a
-	// proper response.
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP Response Error %d\n", resp.StatusCode)
-	}
-
 	// Decode the rss feed document into our struct type.
 	// We don't need to check for errors, the caller can do this.
 	var document rssDocument
-	err = xml.NewDecoder(resp.Body).Decode(&document)
+	err = newXMLDecoder(resp.Body).Decode(&document)
 	return &document, err
 }
@@ -0,0 +1,186 @@
+package matchers
+
+import (
+	"context"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type (
+	// sitemapConfig bounds the crawl of a sitemap feed.
+	sitemapConfig struct {
+		Concurrency int `json:"concurrency"`
+		MaxPages    int `json:"max_pages"`
+	}
+
+	// sitemapLocation is a <url> or <sitemap> entry of a sitemap.
+	sitemapLocation struct {
+		Loc string `xml:"loc"`
+	}
+
+	// sitemapDocument defines the fields of a sitemap or a sitemap index,
+	// see https://www.sitemaps.org/protocol.html.
+	sitemapDocument struct {
+		URLs     []sitemapLocation `xml:"url"`
+		Sitemaps []sitemapLocation `xml:"sitemap"`
+	}
+)
+
+// sitemapMatcher implements the Matcher interface for sites described by
+// a sitemap.xml.
+type sitemapMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher sitemapMatcher
+	search.MustRegister("sitemap", matcher)
+}
+
+// Search reads the sitemap at the feed URI, fetches the pages it lists
+// and returns the pages whose title contains the search term. By default
+// four pages are fetched at a time and at most 100 pages are visited.
+func (m sitemapMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	config := sitemapConfig{Concurrency: 4, MaxPages: 100}
+	if len(feed.Config) > 0 {
+		if err := feed.DecodeConfig(&config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
+
+	pages, err := m.retrieve(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxPages > 0 && len(pages) > config.MaxPages {
+		pages = pages[:config.MaxPages]
+	}
+
+	// Fetch the titles with a fixed number of workers.
+	titles := make([]string, len(pages))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(config.Concurrency)
+	for w := 0; w < config.Concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				title, err := pageTitle(ctx, pages[i])
+				if err != nil {
+					slog.Warn("skip page", "url", pages[i], "error", err)
+					continue
+				}
+				titles[i] = title
+			}
+		}()
+	}
+feed:
+	for i := range pages {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, title := range titles {
+		if query.Match(title) {
+			results = append(results, &search.Result{
+				Field:   pages[i],
+				Content: title,
+				URL:     pages[i],
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// retrieve reads the sitemap and returns the page URLs it lists. The
+// sitemaps listed by a sitemap index are read in turn.
+func (m sitemapMatcher) retrieve(ctx context.Context, feed *search.Feed) ([]string, error) {
+	if feed.URI == "" {
+		return nil, errors.New("no sitemap uri provided")
+	}
+
+	document, err := m.decode(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []string
+	for _, u := range document.URLs {
+		pages = append(pages, strings.TrimSpace(u.Loc))
+	}
+	for _, s := range document.Sitemaps {
+		child, err := m.decode(ctx, strings.TrimSpace(s.Loc))
+		if err != nil {
+			slog.Warn("skip sitemap", "url", s.Loc, "error", err)
+			continue
+		}
+		for _, u := range child.URLs {
+			pages = append(pages, strings.TrimSpace(u.Loc))
+		}
+	}
+	return pages, nil
+}
+
+// decode downloads and decodes one sitemap document.
+func (m sitemapMatcher) decode(ctx context.Context, uri string) (*sitemapDocument, error) {
+	resp, err := get(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var document sitemapDocument
+	err = newXMLDecoder(resp.Body).Decode(&document)
+	return &document, err
+}
+
+// pageTitle downloads a page and returns the text of its <title>.
+func pageTitle(ctx context.Context, uri string) (string, error) {
+	resp, err := get(ctx, uri)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	z := html.NewTokenizer(resp.Body)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return "", nil
+			}
+			return "", z.Err()
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) != atom.Title {
+				continue
+			}
+			if z.Next() != html.TextToken {
+				return "", nil
+			}
+			return strings.Join(strings.Fields(string(z.Text())), " "), nil
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package matchers
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+)
+
+// open returns a reader for uri, which is either an http(s) URL, a
+// file URL, or a path on the local file system. ctx bounds the download
+// of remote files. The content is converted to UTF-8, see toUTF8.
+func open(ctx context.Context, uri string) (io.ReadCloser, error) {
+	if isRemote(uri) {
+		resp, err := get(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	path := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		path = u.Path
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return readCloser{toUTF8(file, ""), file}, nil
+}
+
+// isRemote reports whether uri is an http(s) URL.
+func isRemote(uri string) bool {
+	u, err := url.Parse(uri)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
@@ -0,0 +1,60 @@
+package matchers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"strings"
+)
+
+// tableConfig names the table and columns an SQL feed searches. Key is
+// the column identifying a row in results.
+type tableConfig struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	Key     string   `json:"key"`
+}
+
+// check reports a missing table or column list and defaults the key.
+func (c *tableConfig) check(defaultKey string) error {
+	if c.Table == "" || len(c.Columns) == 0 {
+		return errors.New("config needs a table and columns")
+	}
+	if c.Key == "" {
+		c.Key = defaultKey
+	}
+	return nil
+}
+
+// rowResults turns rows selecting the key followed by the configured
+// columns into one result per row.
+func rowResults(rows *sql.Rows, config tableConfig) ([]*search.Result, error) {
+	var results []*search.Result
+	for rows.Next() {
+		var key sql.NullString
+		values := make([]sql.NullString, len(config.Columns))
+		dest := []interface{}{&key}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		var content []string
+		for i, value := range values {
+			content = append(content, fmt.Sprintf("%s: %s", config.Columns[i], value.String))
+		}
+		results = append(results, &search.Result{
+			Field:   fmt.Sprintf("%s %s=%s", config.Table, config.Key, key.String),
+			Content: strings.Join(content, "\n"),
+		})
+	}
+	return results, rows.Err()
+}
+
+// quoteIdent quotes an SQL identifier so that any name may be used.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
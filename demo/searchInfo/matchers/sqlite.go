@@ -0,0 +1,145 @@
+package matchers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteMatcher implements the Matcher interface for SQLite databases.
+type sqliteMatcher struct{}
+
+// sqliteDriver is the sqlite3 driver with a REGEXP function, which SQLite
+// declares but leaves undefined, for regular expression terms.
+const sqliteDriver = "sqlite3_regexp"
+
+// init registers the matcher with the program.
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", sqliteRegexp, true)
+		},
+	})
+
+	var matcher sqliteMatcher
+	search.MustRegister("sqlite", matcher)
+}
+
+// Search opens the database at the feed URI and returns every row of the
+// configured table matching the query, where a term matches a row when one
+// of the configured columns contains it.
+// Rows are identified by rowid unless the config names another key.
+func (m sqliteMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+
+	var config tableConfig
+	if err := feed.DecodeConfig(&config); err != nil {
+		return nil, err
+	}
+	if err := config.check("rowid"); err != nil {
+		return nil, fmt.Errorf("sqlite %v", err)
+	}
+
+	db, err := sql.Open(sqliteDriver, "file:"+feed.URI+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query, args := m.query(config, search.QueryFromContext(ctx, searchTerm))
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return rowResults(rows, config)
+}
+
+// query builds the SELECT statement for config and its arguments,
+// translating the query expression into the WHERE clause. Literal,
+// case-sensitive terms are found with instr; the other terms go through
+// REGEXP with the pattern of the query, so the case and whole word
+// settings behave as in every other matcher.
+func (m sqliteMatcher) query(config tableConfig, q *search.Query) (string, []interface{}) {
+	columns := make([]string, len(config.Columns))
+	for i, column := range config.Columns {
+		columns[i] = quoteIdent(column)
+	}
+
+	var args []interface{}
+	var where func(n search.Node) string
+	where = func(n search.Node) string {
+		switch n := n.(type) {
+		case *search.Term:
+			format, arg := "instr(ifnull(%s, ''), ?) > 0", n.Text
+			if n.Regexp != nil || !q.CaseSensitive() || q.WholeWord() {
+				format, arg = "ifnull(%s, '') REGEXP ?", q.Pattern(n)
+			}
+			var any []string
+			for _, column := range columns {
+				// NULL would make NOT unknown rather than true.
+				any = append(any, fmt.Sprintf(format, column))
+				args = append(args, arg)
+			}
+			return "(" + strings.Join(any, " OR ") + ")"
+		case *search.And:
+			return "(" + joinWhere(n.Nodes, where, " AND ") + ")"
+		case *search.Or:
+			return "(" + joinWhere(n.Nodes, where, " OR ") + ")"
+		case *search.Not:
+			return "NOT " + where(n.Node)
+		}
+		return "0" // an empty query matches nothing
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s",
+		quoteIdent(config.Key), strings.Join(columns, ", "),
+		quoteIdent(config.Table), where(q.Expr()))
+	return query, args
+}
+
+// joinWhere translates nodes and joins the conditions with sep.
+func joinWhere(nodes []search.Node, where func(search.Node) string, sep string) string {
+	conditions := make([]string, len(nodes))
+	for i, n := range nodes {
+		conditions[i] = where(n)
+	}
+	return strings.Join(conditions, sep)
+}
+
+// sqliteRegexps caches the compiled patterns of the REGEXP function, which
+// is called once per row.
+var sqliteRegexps sync.Map
+
+// sqliteRegexp implements "value REGEXP pattern". NULL never matches.
+func sqliteRegexp(pattern string, value interface{}) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+	re, ok := sqliteRegexps.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		re, _ = sqliteRegexps.LoadOrStore(pattern, compiled)
+	}
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		text = fmt.Sprint(v)
+	}
+	return re.(*regexp.Regexp).MatchString(text), nil
+}
@@ -0,0 +1,38 @@
+package matchers
+
+import (
+	"strings"
+	"time"
+)
+
+// timeLayouts are the date formats found in feeds, most common first.
+// RSS uses RFC 822 dates, often with a four-digit year or a missing
+// leading zero; JSON based APIs use RFC 3339.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseTime parses a publication date in any of the known layouts and
+// returns the zero time if none of them match.
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,125 @@
+package matchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlMatcher implements the Matcher interface for YAML documents.
+type yamlMatcher struct{}
+
+// init registers the matcher with the program.
+func init() {
+	var matcher yamlMatcher
+	search.MustRegister("yaml", matcher)
+}
+
+// Search loads the YAML file at the feed URI, local or remote, and returns
+// every scalar value containing the search term, with its dotted key path
+// as the field. Sequence elements appear in the path as [i].
+func (m yamlMatcher) Search(ctx context.Context, feed *search.Feed, searchTerm string) ([]*search.Result, error) {
+	var results []*search.Result
+
+	slog.DebugContext(ctx, "search feed", "feed", feed.Name, "type", feed.Type, "uri", feed.RedactedURI())
+	query := search.QueryFromContext(ctx, searchTerm)
+
+	if feed.URI == "" {
+		return nil, errors.New("no yaml uri provided")
+	}
+	file, err := open(ctx, feed.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	visit := func(path, value string) {
+		if query.Match(value) {
+			results = append(results, &search.Result{Field: path, Content: value})
+		}
+	}
+
+	// A file may hold several documents separated by ---.
+	decoder := yaml.NewDecoder(file)
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		w := yamlWalker{visit: visit, active: make(map[*yaml.Node]bool)}
+		if err := w.walk(&document, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// maxYAMLAliases bounds how many aliases a document may expand, so
+// that nested aliases cannot blow up exponentially.
+const maxYAMLAliases = 10000
+
+// yamlWalker calls visit with the path and value of every scalar of a
+// document.
+type yamlWalker struct {
+	visit func(path, value string)
+
+	// active holds the anchored nodes being walked, to skip aliases that
+	// refer to an enclosing node.
+	active  map[*yaml.Node]bool
+	aliases int
+}
+
+// walk visits every scalar under node.
+func (w *yamlWalker) walk(node *yaml.Node, path string) error {
+	if node.Anchor != "" {
+		// An alias to an enclosing node would repeat forever.
+		if w.active[node] {
+			return nil
+		}
+		w.active[node] = true
+		defer delete(w.active, node)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := w.walk(child, path); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := w.walk(node.Content[i+1], key); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := w.walk(child, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		if w.aliases++; w.aliases > maxYAMLAliases {
+			return fmt.Errorf("yaml document expands more than %d aliases", maxYAMLAliases)
+		}
+		return w.walk(node.Alias, path)
+	case yaml.ScalarNode:
+		w.visit(path, node.Value)
+	}
+	return nil
+}
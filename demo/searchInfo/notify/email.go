@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Email 通过 SMTP 服务器发送邮件
+type Email struct {
+	// Addr SMTP 服务器的地址，如 smtp.example.com:587
+	Addr string
+	// Username、Password 不为空时使用 PLAIN 认证
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailFromEnv 创建发送给 to 的 Email，服务器的设置来自环境变量：
+// SMTP_ADDR（必需）、SMTP_USERNAME、SMTP_PASSWORD 和 SMTP_FROM，
+// SMTP_FROM 为空时使用 SMTP_USERNAME
+func EmailFromEnv(to []string) (*Email, error) {
+	e := &Email{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		To:       to,
+	}
+	if e.From == "" {
+		e.From = e.Username
+	}
+	switch {
+	case e.Addr == "":
+		return nil, errors.New("email notifier needs SMTP_ADDR")
+	case e.From == "":
+		return nil, errors.New("email notifier needs SMTP_FROM or SMTP_USERNAME")
+	case len(to) == 0 || to[0] == "":
+		return nil, errors.New("email notifier needs a recipient")
+	}
+	return e, nil
+}
+
+// Notify 实现 Notifier，发送一封列出所有结果的纯文本邮件
+// net/smtp 不支持 context，ctx 只在发送前检查
+func (e *Email) Notify(ctx context.Context, searchTerm string, results []*search.Result) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	return smtp.SendMail(e.Addr, auth, e.From, e.To, e.message(searchTerm, results))
+}
+
+// message 生成邮件的内容
+func (e *Email) message(searchTerm string, results []*search.Result) []byte {
+	var b strings.Builder
+	subject := fmt.Sprintf("searchInfo: %d new results for %q", len(results), searchTerm)
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	for _, result := range results {
+		fmt.Fprintf(&b, "[%s] %s\r\n", feedName(result), result.Field)
+		if result.URL != "" {
+			fmt.Fprintf(&b, "%s\r\n", result.URL)
+		}
+		for _, line := range strings.Split(result.Content, "\n") {
+			fmt.Fprintf(&b, "%s\r\n", strings.TrimRight(line, "\r"))
+		}
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,112 @@
+// Package notify 把搜索到的新结果推送到其他地方：webhook、Slack 或电子邮件，
+// 通常与守护模式（search.Watch）一起使用
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Notifier 推送一次搜索的结果
+type Notifier interface {
+	Notify(ctx context.Context, searchTerm string, results []*search.Result) error
+}
+
+// Parse 按 spec 创建 Notifier，用于命令行参数：
+//
+//	https://example.com/hook                 以 JSON 形式 POST 到 webhook
+//	slack:https://hooks.slack.com/services/… 发送到 Slack 的 incoming webhook
+//	mailto:a@example.com,b@example.com       通过 SMTP 发送邮件，见 EmailFromEnv
+func Parse(spec string) (Notifier, error) {
+	switch {
+	case strings.HasPrefix(spec, "slack:"):
+		return &Slack{URL: strings.TrimPrefix(spec, "slack:")}, nil
+	case strings.HasPrefix(spec, "mailto:"):
+		return EmailFromEnv(strings.Split(strings.TrimPrefix(spec, "mailto:"), ","))
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return &Webhook{URL: spec}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q", spec)
+}
+
+// Multi 依次调用所有的 Notifier，返回它们的错误
+func Multi(notifiers ...Notifier) Notifier {
+	return multi(notifiers)
+}
+
+type multi []Notifier
+
+func (m multi) Notify(ctx context.Context, searchTerm string, results []*search.Result) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, searchTerm, results); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Displayer 返回一个 Displayer：结果照常交给 next 输出，全部输出后，
+// 有结果时再一起交给 n 推送；推送失败时 Display 返回该错误
+// 推送的时限为 Timeout
+func Displayer(next search.Displayer, searchTerm string, n Notifier) search.Displayer {
+	return &displayer{next: next, term: searchTerm, notifier: n}
+}
+
+// Timeout 每次推送的时限
+var Timeout = 30 * time.Second
+
+type displayer struct {
+	next     search.Displayer
+	term     string
+	notifier Notifier
+}
+
+func (d *displayer) Display(results <-chan *search.Result) error {
+	var collected []*search.Result
+	err := search.Tee(d.next, func(result *search.Result) {
+		collected = append(collected, result)
+	}).Display(results)
+	if err != nil || len(collected) == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return d.notifier.Notify(ctx, d.term, collected)
+}
+
+// post 把 body 以 contentType POST 到 url，非 2xx 的响应是错误
+func post(ctx context.Context, client *http.Client, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("notify %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// feedName 返回结果所属数据源的名称
+func feedName(result *search.Result) string {
+	if result.Feed == nil {
+		return ""
+	}
+	return result.Feed.Name
+}
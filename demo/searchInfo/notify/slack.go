@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"net/http"
+	"strings"
+)
+
+// slackMaxResults 一条 Slack 消息中最多列出的结果数
+const slackMaxResults = 20
+
+// Slack 把结果发送到 Slack 的 incoming webhook
+type Slack struct {
+	// URL incoming webhook 的地址
+	URL string
+	// Client 发送请求所用的客户端，为空时使用 http.DefaultClient
+	Client *http.Client
+}
+
+// Notify 实现 Notifier，结果较多时只列出前面的一部分
+func (s *Slack) Notify(ctx context.Context, searchTerm string, results []*search.Result) error {
+	body, err := json.Marshal(map[string]string{"text": slackText(searchTerm, results)})
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.Client, s.URL, "application/json", body)
+}
+
+// slackText 生成 Slack mrkdwn 格式的消息
+func slackText(searchTerm string, results []*search.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d new results for* `%s`\n", len(results), slackEscape(searchTerm))
+	for i, result := range results {
+		if i == slackMaxResults {
+			fmt.Fprintf(&b, "… and %d more\n", len(results)-i)
+			break
+		}
+		title := slackEscape(result.Field)
+		if result.URL != "" {
+			title = "<" + result.URL + "|" + title + ">"
+		}
+		content := strings.Join(strings.Fields(result.Content), " ")
+		if r := []rune(content); len(r) > 200 {
+			content = string(r[:200]) + search.Ellipsis
+		}
+		fmt.Fprintf(&b, "• [%s] %s: %s\n", slackEscape(feedName(result)), title, slackEscape(content))
+	}
+	return b.String()
+}
+
+// slackEscape 转义 Slack 消息中的控制字符
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
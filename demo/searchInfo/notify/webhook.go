@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"net/http"
+	"time"
+)
+
+// Webhook 把结果以 JSON 形式 POST 到 URL：
+//
+//	{"query": "...", "time": "...", "results": [{"feed": "...", "field": "...", ...}]}
+type Webhook struct {
+	URL string
+	// Client 发送请求所用的客户端，为空时使用 http.DefaultClient
+	Client *http.Client
+}
+
+// webhookPayload 是 Webhook 发送的内容
+type webhookPayload struct {
+	Query   string          `json:"query"`
+	Time    time.Time       `json:"time"`
+	Results []webhookResult `json:"results"`
+}
+
+// webhookResult 一个结果
+type webhookResult struct {
+	Feed    string     `json:"feed"`
+	Type    string     `json:"type"`
+	Field   string     `json:"field"`
+	Content string     `json:"content"`
+	URL     string     `json:"url,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+}
+
+// Notify 实现 Notifier
+func (w *Webhook) Notify(ctx context.Context, searchTerm string, results []*search.Result) error {
+	payload := webhookPayload{Query: searchTerm, Time: time.Now().UTC()}
+	for _, result := range results {
+		r := webhookResult{
+			Feed:    feedName(result),
+			Field:   result.Field,
+			Content: result.Content,
+			URL:     result.URL,
+		}
+		if result.Feed != nil {
+			r.Type = result.Feed.Type
+		}
+		if !result.Time.IsZero() {
+			t := result.Time
+			r.Time = &t
+		}
+		payload.Results = append(payload.Results, r)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return post(ctx, w.Client, w.URL, "application/json", body)
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// 认证方式
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthAPIKey = "apikey"
+)
+
+// DefaultAPIKeyHeader API key 认证未指定请求头时使用的请求头
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// Auth 访问受保护的数据源所需的认证，凭据不写在数据源文件中，
+// 而是写明从哪个环境变量读取：
+//
+//	{"type": "basic", "username_env": "FEED_USER", "password_env": "FEED_PASS"}
+//	{"type": "bearer", "token_env": "FEED_TOKEN"}
+//	{"type": "apikey", "token_env": "FEED_KEY", "header": "X-Api-Key"}
+type Auth struct {
+	// Type 认证方式：basic、bearer 或 apikey
+	Type string `json:"type"`
+	// UsernameEnv、PasswordEnv basic 认证的用户名和密码所在的环境变量
+	UsernameEnv string `json:"username_env,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+	// TokenEnv bearer 令牌或 API key 所在的环境变量
+	TokenEnv string `json:"token_env,omitempty"`
+	// Header API key 所在的请求头，为空时使用 DefaultAPIKeyHeader
+	Header string `json:"header,omitempty"`
+}
+
+// Apply 从环境变量读取凭据，设置到请求 req 上，环境变量为空时返回错误
+// 基于 HTTP 的匹配器发往数据源 URI 所在主机的请求都会自动调用，
+// 发往其他主机的请求（如站点地图列出的页面）不带凭据
+func (a *Auth) Apply(req *http.Request) error {
+	if a == nil {
+		return nil
+	}
+	switch a.Type {
+	case AuthBasic:
+		username, err := a.lookup("username_env", a.UsernameEnv)
+		if err != nil {
+			return err
+		}
+		// 密码可以为空，但环境变量须已设置
+		var password string
+		if a.PasswordEnv != "" {
+			var ok bool
+			if password, ok = os.LookupEnv(a.PasswordEnv); !ok {
+				return fmt.Errorf("auth: environment variable %s is not set", a.PasswordEnv)
+			}
+		}
+		req.SetBasicAuth(username, password)
+	case AuthBearer:
+		token, err := a.lookup("token_env", a.TokenEnv)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case AuthAPIKey:
+		key, err := a.lookup("token_env", a.TokenEnv)
+		if err != nil {
+			return err
+		}
+		header := a.Header
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		req.Header.Set(header, key)
+	default:
+		return fmt.Errorf("auth: unknown type %q", a.Type)
+	}
+	return nil
+}
+
+// lookup 读取 field 指定的环境变量 name，未指定或为空时返回错误
+func (a *Auth) lookup(field, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("auth: %s auth needs %s", a.Type, field)
+	}
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("auth: environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// validate 检查认证的设置，不检查环境变量是否已设置
+func (a *Auth) validate() error {
+	switch a.Type {
+	case AuthBasic:
+		if a.UsernameEnv == "" {
+			return fmt.Errorf("basic auth needs username_env")
+		}
+	case AuthBearer, AuthAPIKey:
+		if a.TokenEnv == "" {
+			return fmt.Errorf("%s auth needs token_env", a.Type)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", a.Type)
+	}
+	return nil
+}
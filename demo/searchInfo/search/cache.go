@@ -0,0 +1,180 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ResultCache 按（数据源，查询）缓存匹配器返回的结果，在 TTL 内重复搜索时
+// 不再访问数据源。多次 Run 可以共用一个 ResultCache，并发使用是安全的
+type ResultCache struct {
+	ttl time.Duration
+	// dir 不为空时结果同时保存在这个目录，程序重新启动后仍然可用
+	dir string
+
+	mu      sync.Mutex
+	entries map[string]*cachedResults
+}
+
+// cachedResults 一个数据源对一个查询的结果
+type cachedResults struct {
+	Expires time.Time `json:"expires"`
+	Results []*Result `json:"results"`
+}
+
+// NewResultCache 创建结果在 ttl 后过期的缓存，dir 为空时只缓存在内存中
+func NewResultCache(ttl time.Duration, dir string) *ResultCache {
+	return &ResultCache{ttl: ttl, dir: dir, entries: make(map[string]*cachedResults)}
+}
+
+// DefaultResultCacheDir 返回用户缓存目录下保存搜索结果的目录
+func DefaultResultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "searchInfo", "results")
+}
+
+// WithCache 使用缓存 c 中未过期的结果，并把新的结果放入 c
+func WithCache(c *ResultCache) Option {
+	return func(o *options) {
+		o.cache = c
+	}
+}
+
+// Clear 清空缓存，包括保存在目录中的结果
+func (c *ResultCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cachedResults)
+	if c.dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	for _, file := range files {
+		os.Remove(file)
+	}
+	return err
+}
+
+// get 返回数据源 feed 对查询 query 未过期的结果
+// 返回的是副本，之后的处理阶段修改它们不会影响缓存
+func (c *ResultCache) get(feed *Feed, query *Query) ([]*Result, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := cacheKey(feed, query)
+
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+	if entry == nil && c.dir != "" {
+		entry = c.load(key)
+	}
+	if entry == nil || time.Now().After(entry.Expires) {
+		return nil, false
+	}
+	return copyResults(entry.Results), true
+}
+
+// put 保存数据源 feed 对查询 query 的结果
+func (c *ResultCache) put(feed *Feed, query *Query, results []*Result) {
+	if c == nil {
+		return
+	}
+	key := cacheKey(feed, query)
+	entry := &cachedResults{Expires: time.Now().Add(c.ttl), Results: copyResults(results)}
+	for _, result := range entry.Results {
+		// 取出时再设置为当时的数据源
+		if result.Feed == feed {
+			result.Feed = nil
+		}
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.Expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	if c.dir != "" {
+		c.save(key, entry)
+	}
+}
+
+// load 从目录中读取缓存的结果，读取失败时当作没有缓存
+func (c *ResultCache) load(key string) *cachedResults {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var entry cachedResults
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.entries[key] = &entry
+	c.mu.Unlock()
+	return &entry
+}
+
+// save 把结果写入目录，写入失败只记录日志，下次重新搜索即可
+// 结果可能来自需要认证的数据源，与 HTTP 缓存一样只有当前用户可以读取
+func (c *ResultCache) save(key string, entry *cachedResults) {
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(c.dir, 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(c.dir, key+".json"), data, 0600)
+	}
+	if err != nil {
+		slog.Warn("write result cache", "dir", c.dir, "error", err)
+	}
+}
+
+// cacheKey 由数据源的配置和查询计算缓存的键
+// 选项和认证设置也计入其中，修改请求头、查询参数或凭据后不再使用旧的结果
+func cacheKey(feed *Feed, query *Query) string {
+	data, _ := json.Marshal([]interface{}{
+		feed.Name, feed.Type, feed.URI, feed.Config, feed.Options, feed.Auth,
+		query.String(), query.CaseSensitive(), query.WholeWord(),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// copyResults 复制结果及其中的切片
+func copyResults(results []*Result) []*Result {
+	copies := make([]*Result, len(results))
+	for i, result := range results {
+		r := *result
+		r.Sources = append([]string(nil), result.Sources...)
+		if result.Matches != nil {
+			r.Matches = append([]Span{}, result.Matches...)
+		}
+		copies[i] = &r
+	}
+	return copies
+}
+
+// resultCacheKey 是在 context 中保存 ResultCache 的键
+type resultCacheKey struct{}
+
+// cacheFromContext 取出 ctx 中的 ResultCache，没有时返回 nil
+func cacheFromContext(ctx context.Context) *ResultCache {
+	c, _ := ctx.Value(resultCacheKey{}).(*ResultCache)
+	return c
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ClientFactory 返回搜索数据源时发起 HTTP 请求使用的客户端
+// feed 为 nil 表示请求不属于某个数据源，如下载数据源列表
+type ClientFactory func(feed *Feed) *http.Client
+
+var (
+	clientMu      sync.RWMutex
+	clientFactory ClientFactory
+)
+
+// DefaultMaxBodySize HTTP 响应体大小的默认上限
+const DefaultMaxBodySize = 32 << 20
+
+// maxBodySize SetMaxBodySize 设置的上限
+var maxBodySize atomic.Int64
+
+func init() {
+	maxBodySize.Store(DefaultMaxBodySize)
+}
+
+// SetMaxBodySize 设置搜索时读取的 HTTP 响应体的字节数上限，按解压后的大小计算，
+// 超出的部分被截断，避免过大的响应耗尽内存。0 或负数表示不限制
+func SetMaxBodySize(n int64) {
+	maxBodySize.Store(n)
+}
+
+// MaxBodySize 返回 SetMaxBodySize 设置的上限，不大于 0 时不限制
+func MaxBodySize() int64 {
+	return maxBodySize.Load()
+}
+
+// SetHTTPClient 让所有的 HTTP 请求使用 client，调用方借此控制超时、Transport 和代理
+func SetHTTPClient(client *http.Client) {
+	SetClientFactory(func(*Feed) *http.Client { return client })
+}
+
+// SetClientFactory 设置全局的 ClientFactory，为 nil 时使用 http.DefaultClient
+func SetClientFactory(factory ClientFactory) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	clientFactory = factory
+}
+
+// WithHTTPClient 本次搜索使用 client 发起 HTTP 请求
+func WithHTTPClient(client *http.Client) Option {
+	return WithClientFactory(func(*Feed) *http.Client { return client })
+}
+
+// WithClientFactory 本次搜索使用 factory 为每个数据源提供 HTTP 客户端
+func WithClientFactory(factory ClientFactory) Option {
+	return func(o *options) {
+		o.clientFactory = factory
+	}
+}
+
+// clientKey 是在 context 中保存 ClientFactory 的键
+type clientKey struct{}
+
+// HTTPClient 返回在 ctx 中发起 HTTP 请求应使用的客户端：
+// 优先使用 Run 的 WithClientFactory，其次是全局设置，最后是 http.DefaultClient
+// 匹配器发起请求时都应通过它获取客户端，而不是自行创建
+func HTTPClient(ctx context.Context) *http.Client {
+	feed, _ := FromContext(ctx)
+	factory, _ := ctx.Value(clientKey{}).(ClientFactory)
+	return clientOf(factory, feed)
+}
+
+// clientOf 返回 factory 为 feed 提供的客户端，factory 为空时使用全局设置
+func clientOf(factory ClientFactory, feed *Feed) *http.Client {
+	if factory == nil {
+		clientMu.RLock()
+		factory = clientFactory
+		clientMu.RUnlock()
+	}
+	if factory != nil {
+		if client := factory(feed); client != nil {
+			return client
+		}
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 匹配器声明的设置，键为类型
+var (
+	configsMu sync.Mutex
+	configs   = make(map[string]interface{})
+)
+
+// RegisterConfig 声明类型为 feedType 的匹配器的设置，config 是指向设置结构体的指针，
+// 其中的值是默认值；Config.Apply 把配置文件 matchers 节中该类型的设置解码到其中，
+// 字段名按 JSON 标签。通常在注册匹配器的 init 中调用，同一类型重复声明时 panic
+// 设置只在启动时写入，匹配器搜索时直接读取，不必加锁
+func RegisterConfig(feedType string, config interface{}) {
+	configsMu.Lock()
+	defer configsMu.Unlock()
+
+	if _, exists := configs[feedType]; exists {
+		panic(fmt.Sprintf("%s matcher config already registered", feedType))
+	}
+	configs[feedType] = config
+}
+
+// Config 配置文件的内容：
+//
+//	matchers:
+//	  elasticsearch:
+//	    username: reader
+//	    password: secret
+//	  file:
+//	    ignore: [".git", "*.log"]
+type Config struct {
+	// Matchers 各个匹配器的设置，键为类型
+	Matchers map[string]json.RawMessage `json:"matchers"`
+}
+
+// DefaultConfigPath 返回默认的配置文件路径，位于用户配置目录下的 searchInfo/config.yaml
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "searchInfo", "config.yaml")
+}
+
+// LoadConfig 读取配置文件 path，按扩展名识别格式：
+// .yaml/.yml 为 YAML，.toml 为 TOML，其余为 JSON
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML 和 TOML 先转换为 JSON，与数据源文件的处理相同
+	var v map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &v)
+	case ".toml":
+		err = toml.Unmarshal(data, &v)
+	default:
+		err = json.Unmarshal(data, &v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if data, err = json.Marshal(v); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &c, nil
+}
+
+// Apply 把 matchers 节中的设置解码到各个匹配器用 RegisterConfig 声明的结构体，
+// 没有声明设置的类型和未知的字段是错误，便于发现拼写错误
+func (c *Config) Apply() error {
+	configsMu.Lock()
+	defer configsMu.Unlock()
+
+	types := make([]string, 0, len(c.Matchers))
+	for feedType := range c.Matchers {
+		types = append(types, feedType)
+	}
+	sort.Strings(types)
+
+	for _, feedType := range types {
+		config, ok := configs[feedType]
+		if !ok {
+			return fmt.Errorf("matchers.%s: no such matcher settings", feedType)
+		}
+		dec := json.NewDecoder(bytes.NewReader(c.Matchers[feedType]))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(config); err != nil {
+			return fmt.Errorf("matchers.%s: %v", feedType, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SeenSet 记录已经报告过的结果，用于只报告新的结果
+// 按数据源分别记录每个结果的哈希和最后一次见到的时间，见 LoadSeenSet
+// 并发使用是安全的
+type SeenSet struct {
+	mu    sync.Mutex
+	feeds map[string]map[string]time.Time
+
+	// path 和 searchTerm 不为空时 SeenSet 来自状态文件，Save 写回其中
+	path, searchTerm string
+}
+
+// NewSeenSet 创建只保存在内存中的空 SeenSet
+func NewSeenSet() *SeenSet {
+	return &SeenSet{feeds: make(map[string]map[string]time.Time)}
+}
+
+// Add 记录结果，结果此前没有见过时返回 true
+func (s *SeenSet) Add(result *Result) bool {
+	feed, key := seenKey(result)
+	return s.add(feed, key)
+}
+
+// add 记录数据源 feed 中键为 key 的结果，此前没有见过时返回 true
+func (s *SeenSet) add(feed, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := s.feeds[feed]
+	if keys == nil {
+		keys = make(map[string]time.Time)
+		s.feeds[feed] = keys
+	}
+	_, seen := keys[key]
+	keys[key] = time.Now()
+	return !seen
+}
+
+// known 判断数据源 feed 中键为 key 的结果是否见过，见过时更新最后一次见到的时间，
+// 仍在数据源中的结果不会因 SeenRetention 过期
+func (s *SeenSet) known(feed, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, seen := s.feeds[feed][key]; !seen {
+		return false
+	}
+	s.feeds[feed][key] = time.Now()
+	return true
+}
+
+// Len 返回见过的结果数量
+func (s *SeenSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, keys := range s.feeds {
+		n += len(keys)
+	}
+	return n
+}
+
+// seenKey 返回识别结果所用的数据源名称，以及去重所用的键的哈希
+// 保存哈希而不是键本身，状态文件不会随结果内容的长度增长
+func seenKey(result *Result) (feed, key string) {
+	if result.Feed != nil {
+		feed = result.Feed.Name
+	}
+	sum := sha256.Sum256([]byte(dedupKey(result)))
+	return feed, hex.EncodeToString(sum[:16])
+}
+
+// WithNewOnly 只显示 seen 中没有的结果，显示成功后把显示过的结果加入 seen；
+// 因结果数上限或分页没有显示的结果不加入，下次搜索仍然是新的
+// seen 来自 LoadSeenSet 时，搜索成功后应调用 seen.Save 保存，Watch 会自动保存
+func WithNewOnly(seen *SeenSet) Option {
+	return func(o *options) {
+		o.seen = seen
+	}
+}
+
+// NewOnly 在结果通道和显示之间过滤掉 seen 中已有的结果，并把其余结果加入 seen
+// Run 不使用它，而是在结果显示之后才加入 seen，见 WithNewOnly
+func NewOnly(results <-chan *Result, seen *SeenSet) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		for result := range results {
+			if seen.Add(result) {
+				out <- result
+			}
+		}
+	}()
+	return out
+}
+
+// newOnlyStage 是 Run 的增量搜索阶段：filter 过滤掉见过的结果，记下其余结果的键；
+// mark 在显示之前的最后一步记下显示程序取走的结果，显示成功后 commit 把它们加入 seen
+// 键在 filter 中计算，之后的摘要等阶段修改结果内容不影响它
+type newOnlyStage struct {
+	seen *SeenSet
+
+	mu      sync.Mutex
+	pending map[*Result][2]string
+	shown   [][2]string
+}
+
+// newOnly 返回使用 seen 的增量搜索阶段
+func newOnly(seen *SeenSet) *newOnlyStage {
+	return &newOnlyStage{seen: seen, pending: make(map[*Result][2]string)}
+}
+
+// filter 只转发 seen 中没有的结果，本次搜索中重复的结果只转发一次
+func (n *newOnlyStage) filter(results <-chan *Result) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		passed := make(map[[2]string]bool)
+		for result := range results {
+			feed, key := seenKey(result)
+			id := [2]string{feed, key}
+			if n.seen.known(feed, key) || passed[id] {
+				continue
+			}
+			passed[id] = true
+			n.mu.Lock()
+			n.pending[result] = id
+			n.mu.Unlock()
+			out <- result
+		}
+	}()
+	return out
+}
+
+// mark 转发结果，记下被取走的结果
+func (n *newOnlyStage) mark(results <-chan *Result) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		for result := range results {
+			out <- result
+			n.mu.Lock()
+			if id, ok := n.pending[result]; ok {
+				n.shown = append(n.shown, id)
+				delete(n.pending, result)
+			}
+			n.mu.Unlock()
+		}
+	}()
+	return out
+}
+
+// commit 把显示过的结果加入 seen，在显示成功后调用
+func (n *newOnlyStage) commit() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, id := range n.shown {
+		n.seen.add(id[0], id[1])
+	}
+	n.shown = nil
+}
+
+// Watch 按 schedule 反复执行搜索，直到 ctx 被取消：第一次立即执行，
+// 之后每次只显示此前没有出现过的结果。见过的结果保存在内存中；
+// 用 WithNewOnly 传入 LoadSeenSet 读取的 SeenSet 时，每次搜索后写回状态文件，
+// 重新启动后也不会再次报告，第一次搜索也只显示新的结果
+// 某次搜索失败只记录日志，下次照常执行；查询语句无法解析等
+// 每次都会发生的错误直接返回。ctx 被取消时返回 nil
+func Watch(ctx context.Context, searchTerm string, schedule Schedule, opts ...Option) error {
+	if _, err := newQuery(searchTerm, newOptions(opts)); err != nil {
+		return err
+	}
+
+	seen := newOptions(opts).seen
+	if seen == nil {
+		seen = NewSeenSet()
+		opts = append(opts, WithNewOnly(seen))
+	}
+	for {
+		start := time.Now()
+		err := Run(ctx, searchTerm, opts...)
+		var feedErrs Errors
+		// 显示失败时不保存，没有送达的结果下次仍会报告
+		if err == nil || errors.As(err, &feedErrs) {
+			if err := seen.Save(); err != nil {
+				slog.Warn("save seen results", "path", seen.path, "error", err)
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.As(err, &feedErrs):
+			slog.Warn("watch search", "term", searchTerm, "failed feeds", len(feedErrs))
+		case err != nil:
+			slog.Error("watch search", "term", searchTerm, "error", err)
+		}
+
+		next := schedule.Next(start)
+		if next.IsZero() {
+			return nil
+		}
+		slog.Debug("watch sleeping", "term", searchTerm, "seen", seen.Len(), "next", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
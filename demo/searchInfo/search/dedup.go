@@ -0,0 +1,68 @@
+package search
+
+import (
+	"strings"
+)
+
+// WithDedup 合并多个数据源给出的相同结果，只显示第一次出现的结果
+// sources 为 true 时在 Result.Sources 中记录给出该结果的所有数据源，
+// 这需要等全部结果到齐后再显示
+func WithDedup(sources bool) Option {
+	return func(o *options) {
+		o.dedup = true
+		o.dedupSources = sources
+	}
+}
+
+// Dedup 在结果通道和显示之间去重：URL 相同（且字段相同）或内容规范化后相同的结果
+// 只保留第一个。sources 为 true 时在第一个结果的 Sources 中记录给出该结果的
+// 所有数据源；结果发送后不再修改，因此这时要等全部结果到齐才发送，不再流式输出
+func Dedup(results <-chan *Result, sources bool) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		seen := make(map[string]*Result)
+		var kept []*Result
+		for result := range results {
+			key := dedupKey(result)
+			if first, dup := seen[key]; dup {
+				if sources && result.Feed != nil {
+					first.Sources = appendSource(first.Sources, result.Feed.Name)
+				}
+				continue
+			}
+			seen[key] = result
+			if !sources {
+				out <- result
+				continue
+			}
+			if result.Feed != nil {
+				result.Sources = appendSource(result.Sources, result.Feed.Name)
+			}
+			kept = append(kept, result)
+		}
+		for _, result := range kept {
+			out <- result
+		}
+	}()
+	return out
+}
+
+// dedupKey 返回判断结果是否相同所用的键
+func dedupKey(result *Result) string {
+	if result.URL != "" {
+		return "url\x00" + result.URL + "\x00" + result.Field
+	}
+	// 忽略大小写和空白的差异
+	return "content\x00" + strings.ToLower(strings.Join(strings.Fields(result.Content), " "))
+}
+
+// appendSource 把数据源名称加入列表，已存在时不重复添加
+func appendSource(sources []string, name string) []string {
+	for _, s := range sources {
+		if s == name {
+			return sources
+		}
+	}
+	return append(sources, name)
+}
@@ -1,5 +1,7 @@
 package search
 
+import "context"
+
 // 默认匹配器
 type defaultMatcher struct {
 }
@@ -7,10 +9,10 @@ type defaultMatcher struct {
 // init 将默认匹配器注册到程序
 func init() {
 	var matcher defaultMatcher
-	Register("default", matcher)
+	MustRegister("default", matcher)
 }
 
 // Search 实现默认匹配器的行为
-func (m defaultMatcher) Search(feed *Feed, searchTerm string) ([]*Result, error) {
+func (m defaultMatcher) Search(ctx context.Context, feed *Feed, searchTerm string) ([]*Result, error) {
 	return nil, nil
 }
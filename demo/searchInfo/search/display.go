@@ -0,0 +1,236 @@
+package search
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Displayer 输出搜索结果
+// Display 从通道中读取结果直到通道关闭，出错时立即返回，由调用方排空通道
+type Displayer interface {
+	Display(results <-chan *Result) error
+}
+
+// WithDisplayer 使用 d 输出结果，代替默认的 PlainDisplayer
+func WithDisplayer(d Displayer) Option {
+	return func(o *options) {
+		o.displayer = d
+	}
+}
+
+// NewDisplayer 按格式名称创建向 w 输出的 Displayer：plain、json、csv 或 table，
+// 供命令行参数选择输出格式
+func NewDisplayer(format string, w io.Writer) (Displayer, error) {
+	switch strings.ToLower(format) {
+	case "", "plain", "text":
+		return &PlainDisplayer{W: w}, nil
+	case "json":
+		return &JSONDisplayer{W: w}, nil
+	case "csv":
+		return &CSVDisplayer{W: w}, nil
+	case "table":
+		return &TableDisplayer{W: w}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// Display 从每个单独的 goroutine 接收到结果后在终端输出
+func Display(results <-chan *Result) {
+	(&PlainDisplayer{}).Display(results)
+}
+
+// DisplayHighlighted 与 Display 相同，但用 markers 标出每个结果的匹配
+func DisplayHighlighted(results <-chan *Result, markers Markers) {
+	(&PlainDisplayer{Markers: &markers}).Display(results)
+}
+
+// Tee 返回一个 Displayer，把每个结果先交给 fn，再交给 next 输出
+// next 提前返回时，其余的结果仍然交给 fn，Display 返回 next 的错误
+func Tee(next Displayer, fn func(*Result)) Displayer {
+	return &teeDisplayer{next: next, fn: fn}
+}
+
+// teeDisplayer 是 Tee 返回的 Displayer
+type teeDisplayer struct {
+	next Displayer
+	fn   func(*Result)
+}
+
+func (d *teeDisplayer) Display(results <-chan *Result) error {
+	tee := make(chan *Result)
+	done := make(chan error, 1)
+	go func() {
+		done <- d.next.Display(tee)
+	}()
+
+	var (
+		err     error
+		stopped bool
+	)
+	for result := range results {
+		d.fn(result)
+		if !stopped {
+			select {
+			case tee <- result:
+			case err = <-done:
+				stopped = true
+			}
+		}
+	}
+	close(tee)
+	if !stopped {
+		err = <-done
+	}
+	return err
+}
+
+// PlainDisplayer 以 "字段:\n内容" 的文本格式输出
+type PlainDisplayer struct {
+	// W 输出目标，为空时使用标准输出
+	W io.Writer
+	// Markers 不为空时用它标出内容中的匹配
+	Markers *Markers
+}
+
+func (d *PlainDisplayer) Display(results <-chan *Result) error {
+	w := writer(d.W)
+	for result := range results {
+		content := result.Content
+		if d.Markers != nil {
+			content = d.Markers.Apply(content, result.Matches)
+		}
+		if _, err := fmt.Fprintf(w, "%s:\n%s\n\n", result.Field, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONDisplayer 每行输出一个 JSON 对象（JSON Lines），便于流式处理
+type JSONDisplayer struct {
+	// W 输出目标，为空时使用标准输出
+	W io.Writer
+}
+
+func (d *JSONDisplayer) Display(results <-chan *Result) error {
+	enc := json.NewEncoder(writer(d.W))
+	enc.SetEscapeHTML(false)
+	for result := range results {
+		if err := enc.Encode(newRecord(result)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVDisplayer 输出带表头的 CSV
+type CSVDisplayer struct {
+	// W 输出目标，为空时使用标准输出
+	W io.Writer
+}
+
+// csvHeader CSV 输出的列
+var csvHeader = []string{"feed", "type", "field", "url", "time", "score", "content"}
+
+func (d *CSVDisplayer) Display(results <-chan *Result) error {
+	w := csv.NewWriter(writer(d.W))
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+	for result := range results {
+		r := newRecord(result)
+		if err := w.Write([]string{r.Feed, r.Type, r.Field, r.URL, r.timeString(), r.scoreString(), r.Content}); err != nil {
+			return err
+		}
+		// 逐行刷新，使结果及时出现在管道中
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// TableDisplayer 输出列对齐的表格，内容中的换行替换为空格
+// 为了对齐，所有结果到齐后才输出
+type TableDisplayer struct {
+	// W 输出目标，为空时使用标准输出
+	W io.Writer
+}
+
+func (d *TableDisplayer) Display(results <-chan *Result) error {
+	w := tabwriter.NewWriter(writer(d.W), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FEED\tFIELD\tTIME\tSCORE\tCONTENT")
+	for result := range results {
+		r := newRecord(result)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			oneLine(r.Feed), oneLine(r.Field), r.timeString(), r.scoreString(), oneLine(r.Content))
+	}
+	return w.Flush()
+}
+
+// record 结果的输出形式
+type record struct {
+	Feed    string     `json:"feed"`
+	Type    string     `json:"type"`
+	Field   string     `json:"field"`
+	Content string     `json:"content"`
+	URL     string     `json:"url,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+	Score   float64    `json:"score,omitempty"`
+	Matches []Span     `json:"matches,omitempty"`
+	Sources []string   `json:"sources,omitempty"`
+}
+
+func newRecord(result *Result) record {
+	r := record{
+		Field:   result.Field,
+		Content: result.Content,
+		URL:     result.URL,
+		Score:   result.Score,
+		Matches: result.Matches,
+		Sources: result.Sources,
+	}
+	if result.Feed != nil {
+		r.Feed, r.Type = result.Feed.Name, result.Feed.Type
+	}
+	if !result.Time.IsZero() {
+		r.Time = &result.Time
+	}
+	return r
+}
+
+func (r record) timeString() string {
+	if r.Time == nil {
+		return ""
+	}
+	return r.Time.Format(time.RFC3339)
+}
+
+func (r record) scoreString() string {
+	if r.Score == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(r.Score, 'g', -1, 64)
+}
+
+// oneLine 把换行和制表符替换为空格，使内容占一行
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writer 返回输出目标，为空时使用标准输出
+func writer(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
+}
@@ -0,0 +1,30 @@
+package search
+
+import "strings"
+
+// FeedError 记录某个数据源搜索失败的原因
+type FeedError struct {
+	Feed *Feed
+	Err  error
+}
+
+func (e *FeedError) Error() string {
+	return "feed " + e.Feed.Name + " (" + e.Feed.RedactedURI() + "): " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误，便于使用 errors.Is 和 errors.As
+func (e *FeedError) Unwrap() error {
+	return e.Err
+}
+
+// Errors 是 Run 返回的错误，列出每个失败的数据源
+// 调用方可以据此决定部分结果是否可以接受
+type Errors []*FeedError
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
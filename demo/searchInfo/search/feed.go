@@ -1,32 +1,258 @@
 package search
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 const dataFile = "data/data.json"
 
+// DefaultTimeout 数据源未设置 timeout 时，单个数据源的搜索时限
+var DefaultTimeout = 10 * time.Second
+
 // Feed 处理的数据源信息
 type Feed struct {
 	Name string `json:"site"`
 	URI  string `json:"link"`
 	Type string `json:"type"`
+
+	// Tags 数据源的标签，如 "news"，用于有选择地搜索
+	Tags []string `json:"tags,omitempty"`
+
+	// Timeout 单个数据源的搜索时限，如 "30s"，为空时使用 DefaultTimeout
+	Timeout string `json:"timeout,omitempty"`
+
+	// RateLimit 对该数据源所在主机每秒最多发起的请求数，0 表示使用全局设置
+	RateLimit float64 `json:"rate_limit,omitempty"`
+
+	// Weight 排序时该数据源结果得分的倍数，0 表示 1
+	Weight float64 `json:"weight,omitempty"`
+
+	// MaxResults 该数据源最多给出的结果数，0 表示使用 WithFeedMaxResults 的设置
+	MaxResults int `json:"max_results,omitempty"`
+
+	// Options 数据源的附加选项，原样传递给匹配器
+	// 以 "header." 开头的键作为发往数据源 URI 所在主机的 HTTP 请求头，
+	// 以 "query." 开头的键作为其查询参数，如 {"header.X-Api-Key": "...", "query.lang": "en"}，其余的键由匹配器自行解释
+	Options map[string]string `json:"options,omitempty"`
+
+	// Auth 访问数据源所需的认证，凭据从环境变量读取，见 Auth
+	Auth *Auth `json:"auth,omitempty"`
+
+	// Config 匹配器专用的配置，原样保留，由匹配器自行解码
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// timeout 返回数据源的搜索时限，未设置时返回 def
+func (f *Feed) timeout(def time.Duration) (time.Duration, error) {
+	if f.Timeout == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(f.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("bad timeout %q", f.Timeout)
+	}
+	return d, nil
+}
+
+// weight 返回数据源的得分倍数
+func (f *Feed) weight() float64 {
+	if f.Weight == 0 {
+		return 1
+	}
+	return f.Weight
+}
+
+// RedactedURI 返回把密码替换为 xxxxx 的 URI，用于日志、错误和追踪，以免泄露凭据
+// 不能解析为 URL 的 URI 原样返回
+func (f *Feed) RedactedURI() string {
+	u, err := url.Parse(f.URI)
+	if err != nil {
+		return f.URI
+	}
+	return u.Redacted()
+}
+
+// HasTag 判断数据源是否带有标签 tag
+func (f *Feed) HasTag(tag string) bool {
+	for _, t := range f.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Option 返回选项 key 的值，未设置时返回空字符串
+func (f *Feed) Option(key string) string {
+	return f.Options[key]
+}
+
+// OptionsWithPrefix 返回所有以 prefix 开头的选项，键中去掉 prefix
+func (f *Feed) OptionsWithPrefix(prefix string) map[string]string {
+	opts := make(map[string]string)
+	for key, value := range f.Options {
+		if strings.HasPrefix(key, prefix) {
+			opts[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	return opts
+}
+
+// feedKey 是在 context 中保存数据源的键
+type feedKey struct{}
+
+// NewContext 返回携带数据源 feed 的 context
+func NewContext(ctx context.Context, feed *Feed) context.Context {
+	return context.WithValue(ctx, feedKey{}, feed)
+}
+
+// FromContext 取出 ctx 中携带的数据源
+func FromContext(ctx context.Context) (*Feed, bool) {
+	feed, ok := ctx.Value(feedKey{}).(*Feed)
+	return feed, ok
+}
+
+// DecodeConfig 将数据源的 Config 解码到 v 中，未配置时返回错误
+func (f *Feed) DecodeConfig(v interface{}) error {
+	if len(f.Config) == 0 {
+		return fmt.Errorf("no %s config provided", f.Type)
+	}
+	if err := json.Unmarshal(f.Config, v); err != nil {
+		return fmt.Errorf("bad %s config: %v", f.Type, err)
+	}
+	return nil
 }
 
-// RetrieveFeeds 读取并反序列化数据源文件
+// RetrieveFeeds 读取并反序列化默认的数据源文件
 func RetrieveFeeds() ([]*Feed, error) {
+	return LoadFeeds(dataFile)
+}
+
+// LoadFeeds 读取并反序列化数据源文件，按扩展名识别格式：
+// .yaml/.yml 为 YAML，.toml 为 TOML，其余为 JSON
+// YAML 文件可以是数据源的列表，也可以把列表放在 feeds 键下；
+// TOML 文件用 [[feeds]] 表数组列出数据源。字段名与 JSON 相同
+// path 为 http(s) 地址时下载列表，见 FetchFeeds
+func LoadFeeds(path string) ([]*Feed, error) {
+	if isRemote(path) {
+		return FetchFeeds(context.Background(), path, "")
+	}
+
 	// open file
-	file, err := os.Open(dataFile)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	// close file
 	defer file.Close()
 
+	return decodeFeeds(file, strings.ToLower(filepath.Ext(path)))
+}
+
+// decodeFeeds 按格式解码数据源列表
+// YAML 和 TOML 先转换为 JSON，使字段名和 Config 的处理保持一致
+func decodeFeeds(r io.Reader, ext string) ([]*Feed, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext {
+	case ".yaml", ".yml":
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			v = m["feeds"]
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		var v struct {
+			Feeds []map[string]interface{} `toml:"feeds"`
+		}
+		if err := toml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(v.Feeds); err != nil {
+			return nil, err
+		}
+	}
+
 	// 将文件解码到一个切片
 	var feeds []*Feed
-	err = json.NewDecoder(file).Decode(&feeds)
+	err = json.Unmarshal(data, &feeds)
 
 	return feeds, err
 }
+
+// SaveFeeds 把数据源列表写入本地文件 path，按扩展名选择格式，与 LoadFeeds 相同
+// 先写入临时文件再改名，写入失败时不会破坏原文件
+// 新文件的权限为 0644，已有的文件保留原来的权限
+func SaveFeeds(path string, feeds []*Feed) error {
+	if isRemote(path) {
+		return fmt.Errorf("cannot save feeds to %s", path)
+	}
+	data, err := encodeFeeds(feeds, strings.ToLower(filepath.Ext(path)))
+	if err != nil {
+		return err
+	}
+
+	// 临时文件的权限是 0600，改名前换成原文件的权限
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encodeFeeds 按格式编码数据源列表
+// YAML 和 TOML 由 JSON 转换而来，字段名与 JSON 相同
+func encodeFeeds(feeds []*Feed, ext string) ([]byte, error) {
+	data, err := json.MarshalIndent(feeds, "", "\t")
+	if err != nil || (ext != ".yaml" && ext != ".yml" && ext != ".toml") {
+		return data, err
+	}
+
+	var v []interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if ext == ".toml" {
+		var buf bytes.Buffer
+		err := toml.NewEncoder(&buf).Encode(map[string]interface{}{"feeds": v})
+		return buf.Bytes(), err
+	}
+	return yaml.Marshal(map[string]interface{}{"feeds": v})
+}
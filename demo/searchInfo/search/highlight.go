@@ -0,0 +1,62 @@
+package search
+
+import (
+	"strings"
+)
+
+// Span 内容中一处匹配的字节范围 [Start, End)
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// FindMatches 返回 term 在 content 中每一处不重叠出现的位置
+func FindMatches(content, term string) []Span {
+	if term == "" {
+		return nil
+	}
+	var spans []Span
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], term)
+		if i < 0 {
+			return spans
+		}
+		start := offset + i
+		spans = append(spans, Span{Start: start, End: start + len(term)})
+		offset = start + len(term)
+	}
+}
+
+// Markers 高亮匹配时插入在匹配前后的标记
+type Markers struct {
+	Open, Close string
+}
+
+// ANSIMarkers 在终端中以红色粗体显示匹配
+var ANSIMarkers = Markers{Open: "\x1b[1;31m", Close: "\x1b[0m"}
+
+// Apply 在 content 的每一处匹配前后插入标记
+func (m Markers) Apply(content string, spans []Span) string {
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.Start < last || s.End > len(content) {
+			continue
+		}
+		b.WriteString(content[last:s.Start])
+		b.WriteString(m.Open)
+		b.WriteString(content[s.Start:s.End])
+		b.WriteString(m.Close)
+		last = s.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// WithHighlight 显示结果时用 markers 标出内容中的搜索词，如 ANSIMarkers
+// 只对默认的 PlainDisplayer 生效
+func WithHighlight(markers Markers) Option {
+	return func(o *options) {
+		o.markers = &markers
+	}
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"context"
+)
+
+// WithMaxResults 得到 n 个结果（去重后）后停止搜索：取消仍在进行的匹配，
+// 还没有开始的数据源不再搜索，适合在大量数据源中只需要少数几个结果的情况
+// 同时用 WithSort 或 WithRanking 指定了排序时，要比较全部结果才能选出排在最前的 n 个，
+// 这时搜索不会提前结束，排序后只保留前 n 个结果
+func WithMaxResults(n int) Option {
+	return func(o *options) {
+		o.maxResults = n
+	}
+}
+
+// WithFeedMaxResults 每个数据源最多给出 n 个结果，
+// 数据源设置了 max_results 时以数据源的设置为准
+func WithFeedMaxResults(n int) Option {
+	return func(o *options) {
+		o.feedMaxResults = n
+	}
+}
+
+// WithPage 跳过排序后的前 offset 个结果，最多显示 limit 个，limit 为 0 时不限，
+// 配合 Results 分页获取结果
+func WithPage(offset, limit int) Option {
+	return func(o *options) {
+		o.offset, o.limit = offset, limit
+	}
+}
+
+// Limit 最多发送 n 个结果，达到后调用 stop 并丢弃其余的结果
+func Limit(results <-chan *Result, n int, stop func()) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		sent := 0
+		for result := range results {
+			if sent == n {
+				continue
+			}
+			out <- result
+			sent++
+			if sent == n && stop != nil {
+				stop()
+			}
+		}
+	}()
+	return out
+}
+
+// Page 跳过前 offset 个结果，最多发送 limit 个，limit 为 0 时不限
+func Page(results <-chan *Result, offset, limit int) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		i := 0
+		for result := range results {
+			if i >= offset && (limit <= 0 || i < offset+limit) {
+				out <- result
+			}
+			i++
+		}
+	}()
+	return out
+}
+
+// Results 执行搜索并返回结果，而不是显示，供其他程序调用
+// 结果按 Collected 模式收集，配合 WithPage 可以分页获取
+// 有数据源搜索失败时同时返回结果和 Errors
+func Results(ctx context.Context, searchTerm string, opts ...Option) ([]*Result, error) {
+	var c collector
+	opts = append(opts, WithMode(Collected), WithDisplayer(&c))
+	err := Run(ctx, searchTerm, opts...)
+	return c.results, err
+}
+
+// collector 把结果保存下来的 Displayer
+type collector struct {
+	results []*Result
+}
+
+func (c *collector) Display(results <-chan *Result) error {
+	for result := range results {
+		c.results = append(c.results, result)
+	}
+	return nil
+}
+
+// arrange 依次加上结果数上限、排序、摘要和分页的处理阶段
+// 结果数达到上限后调用 stop 取消其余的匹配，stop 可以为空；
+// 指定了排序时上限在排序之后，保留的是排在最前的结果
+func (o *options) arrange(out <-chan *Result, query *Query, stop func()) <-chan *Result {
+	// 结果数达到上限后取消其余的匹配
+	if o.maxResults > 0 && len(o.sort) == 0 {
+		out = Limit(out, o.maxResults, stop)
+	}
+
+	// 排序
+	keys := o.sort
+	if len(keys) == 0 && o.mode == Collected {
+		keys = []SortKey{SortByFeed, SortByField}
+	}
+	if len(keys) > 0 {
+		out = Sort(out, query, keys...)
+	}
+	if o.maxResults > 0 && len(o.sort) > 0 {
+		out = Limit(out, o.maxResults, nil)
+	}
+
+	// 截取摘要，排序时仍使用完整的内容
+	if o.snippet > 0 {
+		out = Snippets(out, o.snippet)
+	}
+
+	// 分页
+	if o.offset > 0 || o.limit > 0 {
+		out = Page(out, o.offset, o.limit)
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// 包中的日志都通过 log/slog 的默认 Logger 输出，调试信息（每个数据源的
+// 耗时和结果数、匹配器的注册等）使用 Debug 级别，可以恢复的问题使用 Warn 级别
+// 程序可以用 NewLogger 创建 Logger，再用 slog.SetDefault 设置级别和格式
+
+// NewLogger 创建输出到 w 的 Logger，format 为 text（默认）或 json，
+// 低于 level 的日志不输出
+func NewLogger(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
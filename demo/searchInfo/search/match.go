@@ -1,36 +1,181 @@
 package search
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Result 搜索结果
 type Result struct {
 	Field   string
 	Content string
+
+	// URL 结果对应的文章地址，匹配器知道时填写
+	URL string
+	// Feed 产生结果的数据源，由 Match 填写
+	Feed *Feed
+	// Sources 去重时记录的、给出同一结果的所有数据源名称
+	Sources []string
+	// Time 结果的发布时间，未知时为零值
+	Time time.Time
+	// Matches 搜索词在 Content 中出现的位置，供显示时高亮
+	Matches []Span
+	// Score 相关度得分，越大越相关；匹配器未给出时由 Rank 计算
+	Score float64
+	// Query 给出结果的搜索词，由 RunQueries 填写
+	Query string
 }
 
 // Matcher 搜索类型的行为
+// ctx 被取消时，Search 应尽快返回
 type Matcher interface {
-	Search(feed *Feed, searchTerm string) ([]*Result, error)
+	Search(ctx context.Context, feed *Feed, searchTerm string) ([]*Result, error)
 }
 
 // Match 匹配函数，由每个goroutine并发执行
-func Match(match Matcher, feed *Feed, searchTerm string, results chan<- *Result) {
-	searchResults, err := match.Search(feed, searchTerm)
+// 每个数据源的搜索受其时限约束，超时即放弃，不会阻塞整个 Run
+// ctx 被取消后不再发送结果，直接返回
+// 搜索失败时返回 *FeedError
+// 数据源设置了 MaxResults 时只发送前 MaxResults 个结果
+func Match(ctx context.Context, match Matcher, feed *Feed, searchTerm string, results chan<- *Result) error {
+	searchResults, err := matchLimited(ctx, match, feed, searchTerm, feed.MaxResults, DefaultTimeout)
 	if err != nil {
-		log.Println(err)
-		return
+		return err
 	}
 	for _, result := range searchResults {
-		results <- result
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return &FeedError{Feed: feed, Err: ctx.Err()}
+		}
+	}
+	return nil
+}
+
+// matchLimited 搜索数据源，返回准备好发送的结果，由调用方逐个或成批发送
+// max 大于 0 时最多返回 max 个结果，数据源没有设置时限时使用 timeout
+func matchLimited(ctx context.Context, match Matcher, feed *Feed, searchTerm string, max int, timeout time.Duration) (searchResults []*Result, err error) {
+	ctx, span := startSpan(ctx, "search.Feed", feedAttributes(feed)...)
+	defer func() { endSpan(span, err) }()
+
+	// 缓存中有未过期的结果时不访问数据源
+	cache, query := cacheFromContext(ctx), QueryFromContext(ctx, searchTerm)
+	if cached, ok := cache.get(feed, query); ok {
+		span.SetAttributes(attribute.Bool("feed.cached", true), attribute.Int("feed.results", len(cached)))
+		slog.DebugContext(ctx, "feed cached", "feed", feed.Name, "type", feed.Type, "results", len(cached))
+		return prepareResults(ctx, feed, searchTerm, cached, max), nil
+	}
+
+	start := time.Now()
+	searchResults, err = searchFeed(ctx, match, feed, searchTerm, timeout)
+	span.SetAttributes(attribute.Int("feed.results", len(searchResults)))
+	attrs := []interface{}{"feed", feed.Name, "type", feed.Type, "duration", time.Since(start)}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// 整个搜索被取消，不是数据源本身的问题
+		slog.DebugContext(ctx, "feed cancelled", attrs...)
+	case err != nil:
+		slog.WarnContext(ctx, "feed failed", append(attrs, "error", err)...)
+	default:
+		slog.DebugContext(ctx, "feed searched", append(attrs, "results", len(searchResults))...)
 	}
+	metricsFromContext(ctx).observe(feed, len(searchResults), time.Since(start), err)
+	if err != nil {
+		return nil, &FeedError{Feed: feed, Err: err}
+	}
+	cache.put(feed, query, searchResults)
+	return prepareResults(ctx, feed, searchTerm, searchResults, max), nil
+}
+
+// prepareResults 截取前 max 个结果，补上它们的数据源和匹配位置
+func prepareResults(ctx context.Context, feed *Feed, searchTerm string, results []*Result, max int) []*Result {
+	if max > 0 && len(results) > max {
+		results = results[:max]
+	}
+	for _, result := range results {
+		if result.Feed == nil {
+			result.Feed = feed
+		}
+		if result.Matches == nil {
+			result.Matches = QueryFromContext(ctx, searchTerm).Find(result.Content)
+		}
+	}
+	return results
 }
 
-// Display 从每个单独的 goroutine 接收到结果后在终端输出
-func Display(results chan *Result) {
-	for result := range results {
-		fmt.Printf("%s:\n%s\n\n", result.Field, result.Content)
+// searchFeed 在数据源的时限内执行匹配器的搜索，数据源没有设置时限时使用 def
+// 即使匹配器不理会 ctx，超时后也会立即返回
+func searchFeed(ctx context.Context, match Matcher, feed *Feed, searchTerm string, def time.Duration) ([]*Result, error) {
+	timeout, err := feed.timeout(def)
+	if err != nil {
+		return nil, err
 	}
+	ctx, cancel := context.WithTimeout(NewContext(ctx, feed), timeout)
+	defer cancel()
+
+	type outcome struct {
+		results []*Result
+		err     error
+	}
+	done := make(chan outcome, 1)
+	inflight.Add(1)
+	go func() {
+		defer inflight.Done()
+		results, err := match.Search(ctx, feed, searchTerm)
+		done <- outcome{results, err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-ctx.Done():
+		o.err = ctx.Err()
+	}
+	if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, timeoutError(timeout)
+	}
+	return o.results, o.err
+}
+
+// inflight 正在执行的匹配器搜索，包括超时或取消后已被放弃、但还没有返回的
+var inflight sync.WaitGroup
+
+// Wait 等待所有正在执行的匹配器搜索返回，包括因超时或取消而被 Run 放弃的，
+// ctx 先结束时返回 ctx.Err()
+// 用于进程退出前让匹配器完成写缓存、关闭连接等收尾工作，调用时不应再开始新的搜索
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// timeoutError 数据源的搜索超过了时限
+type timeoutError time.Duration
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %v", time.Duration(e))
+}
+
+// Timeout 报告这是一个超时错误
+func (e timeoutError) Timeout() bool {
+	return true
+}
+
+// Unwrap 使 errors.Is(err, context.DeadlineExceeded) 成立
+func (e timeoutError) Unwrap() error {
+	return context.DeadlineExceeded
 }
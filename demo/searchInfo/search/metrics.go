@@ -0,0 +1,124 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics 搜索的 Prometheus 指标，按数据源类型分别统计
+// Metrics 本身是一个 prometheus.Collector，嵌入的程序可以把它注册到自己的
+// Registry；也可以用 Handler 单独提供这些指标
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// searched 搜索过的数据源数量，status 为 ok 或 error
+	searched *prometheus.CounterVec
+	// results 每个数据源给出的结果数
+	results *prometheus.HistogramVec
+	// duration 每个数据源的搜索耗时
+	duration *prometheus.HistogramVec
+	// errors 搜索失败的数据源数量，reason 为 timeout、canceled、no_matcher 或 error
+	errors *prometheus.CounterVec
+}
+
+// NewMetrics 创建一组指标
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		searched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "searchinfo_feeds_searched_total",
+			Help: "Number of feeds searched, by feed type and status.",
+		}, []string{"type", "status"}),
+		results: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "searchinfo_feed_results",
+			Help:    "Number of results returned per feed search.",
+			Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100, 200},
+		}, []string{"type"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "searchinfo_feed_duration_seconds",
+			Help:    "Time taken to fetch and search a feed.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"type"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "searchinfo_feed_errors_total",
+			Help: "Number of failed feed searches, by feed type and reason.",
+		}, []string{"type", "reason"}),
+	}
+	m.registry.MustRegister(m)
+	return m
+}
+
+// Describe 实现 prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.searched.Describe(ch)
+	m.results.Describe(ch)
+	m.duration.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.searched.Collect(ch)
+	m.results.Collect(ch)
+	m.duration.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+// Handler 返回以 Prometheus 格式提供这些指标的 http.Handler
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observe 记录一个数据源的搜索结果，m 为空时什么也不做
+func (m *Metrics) observe(feed *Feed, results int, elapsed time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+		m.errors.WithLabelValues(feed.Type, errorReason(err)).Inc()
+	} else {
+		m.results.WithLabelValues(feed.Type).Observe(float64(results))
+	}
+	m.searched.WithLabelValues(feed.Type, status).Inc()
+	m.duration.WithLabelValues(feed.Type).Observe(elapsed.Seconds())
+}
+
+// errNoMatcher 数据源的类型没有注册匹配器
+var errNoMatcher = errors.New("no matcher")
+
+// errorReason 返回错误的分类，用作指标的标签
+func errorReason(err error) string {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, errNoMatcher):
+		return "no_matcher"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return "timeout"
+	}
+	return "error"
+}
+
+// metricsKey 是在 context 中保存 Metrics 的键
+type metricsKey struct{}
+
+// metricsFromContext 取出 ctx 中的 Metrics，没有时返回 nil
+func metricsFromContext(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsKey{}).(*Metrics)
+	return m
+}
+
+// WithMetrics 把每个数据源的搜索记录到 m
+func WithMetrics(m *Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"context"
+	"sync"
+)
+
+// Middleware 包装匹配器，用于给所有类型的匹配器统一加上计时、日志、重试、限流等功能，
+// 不必在每个匹配器中各自实现
+type Middleware func(Matcher) Matcher
+
+// MatcherFunc 把函数转换为 Matcher，便于在 Middleware 中返回新的匹配器
+type MatcherFunc func(ctx context.Context, feed *Feed, searchTerm string) ([]*Result, error)
+
+// Search 实现 Matcher
+func (f MatcherFunc) Search(ctx context.Context, feed *Feed, searchTerm string) ([]*Result, error) {
+	return f(ctx, feed, searchTerm)
+}
+
+// 全局的中间件，按安装的顺序排列
+var (
+	middlewareMu sync.RWMutex
+	middleware   []Middleware
+)
+
+// Use 安装全局的中间件，之后每次搜索的所有匹配器都会经过它们
+// 先安装的在外层，先于后安装的看到每次搜索
+func Use(mw ...Middleware) {
+	middlewareMu.Lock()
+	defer middlewareMu.Unlock()
+	middleware = append(middleware, mw...)
+}
+
+// WithMiddleware 只为本次搜索安装中间件，它们在全局中间件的里层
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, mw...)
+	}
+}
+
+// Chain 把多个中间件组合为一个，mw[0] 在最外层
+func Chain(mw ...Middleware) Middleware {
+	return func(matcher Matcher) Matcher {
+		for i := len(mw) - 1; i >= 0; i-- {
+			matcher = mw[i](matcher)
+		}
+		return matcher
+	}
+}
+
+// chain 返回本次搜索使用的中间件：全局的在外层，然后是 WithMiddleware 安装的，
+// 最里层是重试，中间件看到的每次搜索都包括了其中的重试
+func (o *options) chain() Middleware {
+	middlewareMu.RLock()
+	mw := append([]Middleware(nil), middleware...)
+	middlewareMu.RUnlock()
+
+	mw = append(mw, o.middleware...)
+	mw = append(mw, func(matcher Matcher) Matcher {
+		return Retry(matcher, o.retry)
+	})
+	return Chain(mw...)
+}
@@ -0,0 +1,36 @@
+package search
+
+// Mode 结果的显示方式
+type Mode int
+
+const (
+	// Streaming 结果到达后立即显示，适合交互使用，是默认方式
+	Streaming Mode = iota
+	// Collected 收集全部结果，排序后一次性显示，适合脚本使用
+	// 未指定排序依据时按数据源和字段排序，使输出可以比较；
+	// 收集本身不去重，需要去重时另外使用 WithDedup
+	Collected
+)
+
+// WithMode 设置结果的显示方式
+func WithMode(mode Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// Collect 收集通道中的全部结果，通道关闭后再依次发送
+func Collect(results <-chan *Result) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		var collected []*Result
+		for result := range results {
+			collected = append(collected, result)
+		}
+		for _, result := range collected {
+			out <- result
+		}
+	}()
+	return out
+}
@@ -0,0 +1,227 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// RunQueries 一次搜索多个查询：所有查询共用一次数据源列表的加载和一个工作池，
+// 每个数据源依次按各个查询搜索，结果记下给出它的搜索词（Result.Query），
+// 返回以搜索词为键的结果。同一数据源的各个查询共用 HTTP 响应，
+// 避免对每个搜索词分别调用 Run 时重复下载所有的数据源
+//
+// 搜索词的解析方式与 Run 相同，任一搜索词无法解析时返回错误，不会开始搜索；
+// 重复的搜索词只搜索一次。结果按 Collected 模式收集，WithDedup、WithMaxResults、
+// WithSort、WithSnippets 和 WithPage 作用于每个查询各自的结果，WithDisplayer 不起作用
+// 结果数上限不会提前结束搜索，因为其他查询可能还需要更多的结果
+// 有数据源搜索失败时同时返回已得到的结果和 Errors
+func RunQueries(ctx context.Context, searchTerms []string, opts ...Option) (map[string][]*Result, error) {
+	o := newOptions(append(opts, WithMode(Collected)))
+
+	// 先解析所有的查询
+	var (
+		queries []termQuery
+		seen    = make(map[string]bool)
+		nodes   []Node
+	)
+	for _, term := range searchTerms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		query, err := newQuery(term, o)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, termQuery{term, query})
+		if query.expr != nil {
+			nodes = append(nodes, query.expr)
+		}
+	}
+	union := newQueryOf(combine(AnyTerm, nodes), o.caseSensitive, o.wholeWord)
+
+	// 搜索一次，每个数据源按各个查询分别搜索；去重、结果数上限、排序和分页留到分组后处理
+	var c collector
+	err := Run(ctx, union.String(), append(opts, func(o *options) {
+		o.query = union
+		o.queries = queries
+		o.clientFactory = shareResponses(o.clientFactory)
+		o.mode = Streaming
+		o.dedup = false
+		o.maxResults, o.sort, o.snippet, o.offset, o.limit = 0, nil, 0, 0, 0
+		o.displayer = &c
+	})...)
+
+	grouped := make(map[string][]*Result, len(queries))
+	for _, q := range queries {
+		in := make(chan *Result)
+		go func(term string) {
+			defer close(in)
+			for _, result := range c.results {
+				if result.Query == term {
+					in <- result
+				}
+			}
+		}(q.term)
+		var out <-chan *Result = in
+		if o.dedup {
+			out = Dedup(out, o.dedupSources)
+		}
+		var results []*Result
+		for result := range o.arrange(out, q.query, nil) {
+			results = append(results, result)
+		}
+		grouped[q.term] = results
+	}
+	return grouped, err
+}
+
+// termQuery RunQueries 中的一个查询及其搜索词
+type termQuery struct {
+	term  string
+	query *Query
+}
+
+// matchQueries 搜索数据源，设置了 queries 时按每个查询分别搜索，
+// 每个查询最多 max 个结果，结果的 Query 记下各自的搜索词
+// 任一查询失败时整个数据源算作失败
+func (o *options) matchQueries(ctx context.Context, matcher Matcher, feed *Feed, searchTerm string, max int) ([]*Result, error) {
+	if len(o.queries) == 0 {
+		return matchLimited(ctx, matcher, feed, searchTerm, max, o.timeout)
+	}
+	var all []*Result
+	for _, q := range o.queries {
+		results, err := matchLimited(NewQueryContext(ctx, q.query), matcher, feed, q.term, max, o.timeout)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			// 结果可能来自缓存，由其他搜索共用，记下搜索词的是副本
+			copied := *result
+			copied.Query = q.term
+			all = append(all, &copied)
+		}
+	}
+	return all, nil
+}
+
+// shareResponses 包装 factory，使同一数据源的 GET 请求在本次搜索中只发送一次，
+// 之后相同的请求得到同一响应的副本；factory 为空时包装全局设置
+func shareResponses(factory ClientFactory) ClientFactory {
+	var (
+		mu      sync.Mutex
+		clients = make(map[*Feed]*http.Client)
+	)
+	return func(feed *Feed) *http.Client {
+		mu.Lock()
+		defer mu.Unlock()
+		if client, ok := clients[feed]; ok {
+			return client
+		}
+		client := *clientOf(factory, feed)
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		client.Transport = &sharedTransport{base: transport, responses: make(map[string]*sharedResponse)}
+		clients[feed] = &client
+		return &client
+	}
+}
+
+// sharedTransport 保存 GET 请求的响应，相同的请求返回响应的副本
+// 只保存 200 和 304 的响应，其他状态码和失败的请求之后会重新发送，重试才有意义；
+// 响应体超过 MaxBodySize 时也不保存，照常交给调用方，由它截断
+type sharedTransport struct {
+	base      http.RoundTripper
+	mu        sync.Mutex
+	responses map[string]*sharedResponse
+}
+
+// sharedResponse 一个请求的响应，done 关闭后其余字段才可以读取
+// ok 为 false 时响应没有保存，等待它的请求需要自己发送
+type sharedResponse struct {
+	done chan struct{}
+	ok   bool
+	resp *http.Response
+	body []byte
+}
+
+func (t *sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+
+	t.mu.Lock()
+	shared, ok := t.responses[key]
+	if ok {
+		t.mu.Unlock()
+		<-shared.done
+		if !shared.ok {
+			return t.base.RoundTrip(req)
+		}
+		return shared.copy(req), nil
+	}
+	shared = &sharedResponse{done: make(chan struct{})}
+	t.responses[key] = shared
+	t.mu.Unlock()
+
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		resp, err = shared.keep(resp)
+	}
+	if !shared.ok {
+		t.mu.Lock()
+		delete(t.responses, key)
+		t.mu.Unlock()
+	}
+	close(shared.done)
+	if err != nil || !shared.ok {
+		return resp, err
+	}
+	return shared.copy(req), nil
+}
+
+// keep 读取并保存可以共用的响应，不能共用的响应原样返回
+func (r *sharedResponse) keep(resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		return resp, nil
+	}
+	limit := MaxBodySize()
+	body := io.Reader(resp.Body)
+	if limit > 0 {
+		body = io.LimitReader(body, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		// 太大的响应不保存，已经读出的部分放回响应体
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	r.ok, r.resp, r.body = true, resp, data
+	return resp, nil
+}
+
+// readCloser 从 Reader 读取，关闭时关闭 Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// copy 返回可以独立读取和修改的响应副本
+func (r *sharedResponse) copy(req *http.Request) *http.Response {
+	resp := *r.resp
+	resp.Header = r.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(r.body))
+	resp.Request = req
+	return &resp
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+type (
+	// opmlDocument OPML 文件，RSS 阅读器导入导出订阅时使用的格式
+	opmlDocument struct {
+		XMLName xml.Name    `xml:"opml"`
+		Version string      `xml:"version,attr"`
+		Title   string      `xml:"head>title,omitempty"`
+		Created string      `xml:"head>dateCreated,omitempty"`
+		Body    []opmlEntry `xml:"body>outline"`
+	}
+
+	// opmlEntry 一个 outline，有 xmlUrl 时是一个订阅，否则是一个文件夹
+	opmlEntry struct {
+		Text     string      `xml:"text,attr"`
+		Title    string      `xml:"title,attr,omitempty"`
+		Type     string      `xml:"type,attr,omitempty"`
+		XMLURL   string      `xml:"xmlUrl,attr,omitempty"`
+		Category string      `xml:"category,attr,omitempty"`
+		Children []opmlEntry `xml:"outline"`
+	}
+)
+
+// ImportOPML 读取 OPML 文件中的订阅，每个带有 xmlUrl 的 outline 是一个数据源：
+// 名称取 title 或 text，类型取 type（默认 rss），
+// 所在的文件夹名和 category 属性中的分类作为标签
+func ImportOPML(r io.Reader) ([]*Feed, error) {
+	var doc opmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var feeds []*Feed
+	var walk func(entries []opmlEntry, folders []string)
+	walk = func(entries []opmlEntry, folders []string) {
+		for _, e := range entries {
+			if e.XMLURL == "" {
+				name := strings.TrimSpace(e.Title)
+				if name == "" {
+					name = strings.TrimSpace(e.Text)
+				}
+				if name != "" {
+					walk(e.Children, append(folders[:len(folders):len(folders)], name))
+				} else {
+					walk(e.Children, folders)
+				}
+				continue
+			}
+			feed := &Feed{Name: strings.TrimSpace(e.Title), URI: strings.TrimSpace(e.XMLURL), Type: strings.ToLower(e.Type)}
+			if feed.Name == "" {
+				feed.Name = strings.TrimSpace(e.Text)
+			}
+			if feed.Type == "" {
+				feed.Type = "rss"
+			}
+			feed.Tags = opmlTags(folders, e.Category)
+			feeds = append(feeds, feed)
+			walk(e.Children, folders)
+		}
+	}
+	walk(doc.Body, nil)
+	if len(feeds) == 0 {
+		return nil, errors.New("no subscriptions found in opml")
+	}
+	return feeds, nil
+}
+
+// opmlTags 合并文件夹名和 category 属性中的分类，去掉重复的
+// category 是逗号分隔的列表，每一项可以是 / 分隔的路径，只取最后一段
+func opmlTags(folders []string, category string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, folder := range folders {
+		add(folder)
+	}
+	for _, c := range strings.Split(category, ",") {
+		add(c[strings.LastIndex(c, "/")+1:])
+	}
+	return tags
+}
+
+// ExportOPML 把数据源写成 OPML 2.0 文件，供 RSS 阅读器导入
+// 每个数据源是一个 outline，类型写在 type 属性中，标签写在 category 属性中
+func ExportOPML(w io.Writer, feeds []*Feed) error {
+	doc := opmlDocument{
+		Version: "2.0",
+		Title:   "searchInfo feeds",
+		Created: time.Now().UTC().Format(time.RFC1123Z),
+	}
+	for _, feed := range feeds {
+		doc.Body = append(doc.Body, opmlEntry{
+			Text:     feed.Name,
+			Title:    feed.Name,
+			Type:     feed.Type,
+			XMLURL:   feed.URI,
+			Category: strings.Join(feed.Tags, ","),
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
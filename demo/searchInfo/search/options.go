@@ -0,0 +1,191 @@
+package search
+
+import (
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option 配置一次 Run 的行为
+type Option func(*options)
+
+// options Run 的可选配置
+type options struct {
+	// workers 同时搜索的数据源数量上限，0 表示每个数据源一个 goroutine
+	workers int
+	// buffer 结果通道的缓冲区大小
+	buffer int
+	// retry 临时性错误的重试策略
+	retry RetryPolicy
+	// middleware WithMiddleware 安装的中间件
+	middleware []Middleware
+	// pluginDir 匹配器插件所在的目录，为空时不加载插件
+	pluginDir string
+	// fanOut 是否把数据源交给该类型的所有匹配器
+	fanOut bool
+	// feedFile 数据源文件的路径
+	feedFile string
+	// feedCache 远程数据源列表的本地缓存
+	feedCache string
+	// watcher 不为空时从中获取数据源列表
+	watcher *FeedWatcher
+	// tags 只搜索带有其中任一标签的数据源，为空时搜索全部
+	tags []string
+	// clientFactory 本次搜索使用的 ClientFactory
+	clientFactory ClientFactory
+	// dedup 是否去除重复的结果，dedupSources 是否记录结果的所有数据源
+	dedup        bool
+	dedupSources bool
+	// sort 显示前结果的排序依据，为空时按到达顺序显示
+	sort []SortKey
+	// markers 不为空时高亮显示结果中的搜索词
+	markers *Markers
+	// snippet 大于 0 时把内容截成该长度的摘要
+	snippet int
+	// displayer 输出结果，为空时使用 PlainDisplayer
+	displayer Displayer
+	// mode 流式显示还是收集后一次显示
+	mode Mode
+	// maxResults 大于 0 时得到这么多结果后停止搜索
+	maxResults int
+	// feedMaxResults 大于 0 时每个数据源最多给出的结果数
+	feedMaxResults int
+	// offset、limit 分页显示结果
+	offset, limit int
+	// terms 额外的搜索词，combinator 搜索词的组合方式
+	terms      []string
+	combinator Combinator
+	// query 不为空时直接使用该查询，不再解析搜索词，见 RunQueries
+	query *Query
+	// queries 不为空时每个数据源按其中的查询分别搜索，结果记下各自的搜索词，见 RunQueries
+	queries []termQuery
+	// regexp 是否把搜索词整个作为正则表达式
+	regexp bool
+	// caseSensitive 是否区分大小写，wholeWord 是否只匹配完整的单词
+	caseSensitive bool
+	wholeWord     bool
+	// language 过滤停用词所用的语言，为空时不过滤
+	language string
+	// timeout 数据源没有设置时限时的搜索时限
+	timeout time.Duration
+	// output 默认 Displayer 的输出目标，为空时使用标准输出
+	output io.Writer
+	// types 只搜索这些类型的数据源，为空时搜索全部
+	types []string
+	// progress 不为空时报告搜索进度
+	progress func(Progress)
+	// metrics 不为空时记录每个数据源的搜索指标
+	metrics *Metrics
+	// cache 不为空时使用其中缓存的结果
+	cache *ResultCache
+	// seen 不为空时只显示其中没有的结果
+	seen *SeenSet
+	// tracerProvider 创建 span 所用的 TracerProvider，为空时使用全局的
+	tracerProvider trace.TracerProvider
+}
+
+// newOptions 应用所有配置项
+func newOptions(opts []Option) *options {
+	o := &options{buffer: DefaultBuffer, retry: DefaultRetry, feedFile: dataFile, caseSensitive: true, language: DefaultLanguage, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithWorkers 使用 n 个 goroutine 组成的工作池搜索数据源，
+// 避免数据源很多时同时打开过多的连接
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// DefaultBuffer 结果通道默认的缓冲区大小
+const DefaultBuffer = 64
+
+// WithBuffer 设置结果通道的缓冲区大小，n 为 0 时不缓冲，每个结果都要等显示的一方取走
+// 缓冲区让匹配器不必和显示结果的 goroutine 一一同步，数据源多且快时吞吐量更高
+func WithBuffer(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			n = 0
+		}
+		o.buffer = n
+	}
+}
+
+// WithFanOut 让每个数据源同时交给该类型注册的所有匹配器查找，合并它们的结果；
+// 默认只使用优先级最高的匹配器
+func WithFanOut() Option {
+	return func(o *options) {
+		o.fanOut = true
+	}
+}
+
+// WithFeedsFile 从 path 读取数据源列表，代替默认的 data/data.json
+// path 可以是本地文件，也可以是 http(s) 地址
+// 支持 JSON、YAML 和 TOML 格式，见 LoadFeeds
+func WithFeedsFile(path string) Option {
+	return func(o *options) {
+		o.feedFile = path
+	}
+}
+
+// WithTags 只搜索带有 tags 中任一标签的数据源
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithTimeout 设置单个数据源的搜索时限，代替 DefaultTimeout；
+// 数据源自己设置了 timeout 时以数据源的设置为准，d 不大于 0 时使用 DefaultTimeout
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d <= 0 {
+			d = DefaultTimeout
+		}
+		o.timeout = d
+	}
+}
+
+// WithOutput 把结果输出到 w，代替标准输出；使用 WithDisplayer 时由 Displayer 决定输出目标
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
+
+// WithMatchersOnly 只搜索类型为 types 之一的数据源，如 WithMatchersOnly("rss", "jsonfeed")
+func WithMatchersOnly(types ...string) Option {
+	return func(o *options) {
+		o.types = append(o.types, types...)
+	}
+}
+
+// selected 判断数据源是否在本次搜索的范围内
+func (o *options) selected(feed *Feed) bool {
+	if len(o.types) > 0 {
+		found := false
+		for _, t := range o.types {
+			if feed.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(o.tags) == 0 {
+		return true
+	}
+	for _, tag := range o.tags {
+		if feed.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sync"
+)
+
+// 已加载的插件文件，同一个文件只注册一次
+var (
+	pluginsMu sync.Mutex
+	plugins   = make(map[string]bool)
+)
+
+// WithPlugins 在搜索开始前加载 dir 目录下的匹配器插件
+func WithPlugins(dir string) Option {
+	return func(o *options) {
+		o.pluginDir = dir
+	}
+}
+
+// LoadPlugins 加载 dir 目录下所有的 .so 插件并注册其中的匹配器
+// 插件需导出两个符号：
+//
+//	var FeedType = "mytype"         // 匹配器处理的数据源类型
+//	var Matcher search.Matcher = ... // 匹配器
+//
+// 插件用 go build -buildmode=plugin 编译
+func LoadPlugins(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	for _, path := range paths {
+		if plugins[path] {
+			continue
+		}
+		if err := loadPlugin(path); err != nil {
+			return fmt.Errorf("plugin %s: %v", path, err)
+		}
+		plugins[path] = true
+	}
+	return nil
+}
+
+// loadPlugin 打开一个插件文件并注册其中的匹配器
+func loadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return err
+	}
+
+	sym, err := p.Lookup("FeedType")
+	if err != nil {
+		return err
+	}
+	feedType, ok := sym.(*string)
+	if !ok {
+		return fmt.Errorf("FeedType is %T, not string", sym)
+	}
+
+	sym, err = p.Lookup("Matcher")
+	if err != nil {
+		return err
+	}
+	var matcher Matcher
+	switch m := sym.(type) {
+	case *Matcher:
+		matcher = *m
+	case Matcher:
+		matcher = m
+	}
+	if matcher == nil {
+		return fmt.Errorf("Matcher is %T, not a search.Matcher", sym)
+	}
+
+	slog.Info("load plugin", "path", path)
+	return Register(*feedType, matcher)
+}
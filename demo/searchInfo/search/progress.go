@@ -0,0 +1,88 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Progress 搜索进度，每个数据源搜索结束时报告一次
+type Progress struct {
+	// Total 需要搜索的数据源数量
+	Total int
+	// Completed 搜索成功的数据源数量，Failed 搜索失败的数据源数量
+	Completed int
+	Failed    int
+	// Feed 刚刚结束的数据源，Err 是它失败的原因；开始搜索时的报告中都为空
+	Feed *Feed
+	Err  error
+}
+
+// Pending 返回还没有结束的数据源数量
+func (p Progress) Pending() int {
+	return p.Total - p.Completed - p.Failed
+}
+
+// Done 报告是否所有数据源都已结束
+func (p Progress) Done() bool {
+	return p.Pending() == 0
+}
+
+// WithProgress 在开始搜索和每个数据源结束时调用 fn 报告进度
+// fn 的调用是串行的，但不在显示结果的 goroutine 中，应尽快返回
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// tracker 统计进度并调用回调
+type tracker struct {
+	mu       sync.Mutex
+	progress Progress
+	fn       func(Progress)
+}
+
+// newTracker 创建搜索 total 个数据源的 tracker，fn 为空时什么也不做
+func newTracker(total int, fn func(Progress)) *tracker {
+	t := &tracker{progress: Progress{Total: total}, fn: fn}
+	if fn != nil {
+		fn(t.progress)
+	}
+	return t
+}
+
+// finish 记录数据源 feed 结束，err 不为空表示失败
+func (t *tracker) finish(feed *Feed, err error) {
+	if t.fn == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.progress.Failed++
+	} else {
+		t.progress.Completed++
+	}
+	t.progress.Feed, t.progress.Err = feed, err
+	t.fn(t.progress)
+}
+
+// ProgressBar 返回在 w 上绘制进度条的回调，供 WithProgress 使用
+// 进度条在同一行刷新，所有数据源结束后换行；w 应当是终端，通常是标准错误
+func ProgressBar(w io.Writer) func(Progress) {
+	const width = 30
+	return func(p Progress) {
+		filled := width
+		if p.Total > 0 {
+			filled = width * (p.Completed + p.Failed) / p.Total
+		}
+		fmt.Fprintf(w, "\r\x1b[K[%s%s] %d/%d 完成，%d 失败，%d 待处理",
+			strings.Repeat("#", filled), strings.Repeat(" ", width-filled),
+			p.Completed, p.Total, p.Failed, p.Pending())
+		if p.Done() {
+			fmt.Fprintln(w)
+		}
+	}
+}
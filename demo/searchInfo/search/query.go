@@ -0,0 +1,615 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Combinator 多个搜索词的组合方式
+type Combinator int
+
+const (
+	// AllTerms 所有搜索词都出现才算匹配
+	AllTerms Combinator = iota
+	// AnyTerm 任一搜索词出现就算匹配
+	AnyTerm
+)
+
+// Node 查询语法树的节点：*Term、*And、*Or 或 *Not
+// 能把查询交给后端的匹配器遍历语法树，把它翻译成后端的查询
+type Node interface {
+	fmt.Stringer
+	node()
+}
+
+// Term 一个搜索词，文本中出现即匹配
+// Regexp 不为空时是正则表达式搜索词，Text 为表达式的源码，文本中有匹配即匹配
+type Term struct {
+	Text   string
+	Regexp *regexp.Regexp
+}
+
+// And 所有子节点都匹配才匹配
+type And struct {
+	Nodes []Node
+}
+
+// Or 任一子节点匹配就匹配
+type Or struct {
+	Nodes []Node
+}
+
+// Not 子节点不匹配时匹配
+type Not struct {
+	Node Node
+}
+
+func (*Term) node() {}
+func (*And) node()  {}
+func (*Or) node()   {}
+func (*Not) node()  {}
+
+func (t *Term) String() string {
+	if t.Regexp != nil {
+		if t.Text == "" || strings.ContainsAny(t.Text, " \t\n\"") {
+			return `re:"` + strings.ReplaceAll(t.Text, `"`, `\"`) + `"`
+		}
+		return "re:" + t.Text
+	}
+	if t.Text == "" || strings.ContainsAny(t.Text, " \t\n()\"") || keyword(t.Text) != "" || strings.HasPrefix(t.Text, regexpPrefix) {
+		return `"` + strings.ReplaceAll(t.Text, `"`, `\"`) + `"`
+	}
+	return t.Text
+}
+
+func (a *And) String() string { return joinNodes(a.Nodes, " AND ") }
+func (o *Or) String() string  { return joinNodes(o.Nodes, " OR ") }
+func (n *Not) String() string { return "NOT " + group(n.Node) }
+
+// joinNodes 以 sep 连接子节点的文本形式，组合节点加括号
+func joinNodes(nodes []Node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = group(n)
+	}
+	return strings.Join(parts, sep)
+}
+
+// group 返回节点的文本形式，And 和 Or 加括号
+func group(n Node) string {
+	switch n.(type) {
+	case *And, *Or:
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+// Query 一次搜索的查询条件，由 Run 创建并通过 ctx 传给匹配器
+// 匹配器用 Match 判断文本是否匹配，使所有匹配器的大小写和整词规则一致；
+// 需要把查询交给后端时，遍历 Expr 翻译成后端的查询，搜索词用 Pattern 转换，
+// 或者使用 Terms 和 Combinator 做近似的查询
+type Query struct {
+	expr          Node
+	caseSensitive bool
+	wholeWord     bool
+	// patterns 按大小写和整词设置编译的搜索词，按字面区分大小写匹配的搜索词不在其中
+	patterns map[*Term]*regexp.Regexp
+}
+
+// newQueryOf 创建表达式为 expr 的查询，并按设置编译搜索词
+func newQueryOf(expr Node, caseSensitive, wholeWord bool) *Query {
+	q := &Query{
+		expr:          expr,
+		caseSensitive: caseSensitive,
+		wholeWord:     wholeWord,
+		patterns:      make(map[*Term]*regexp.Regexp),
+	}
+	for _, t := range q.terms(false) {
+		if t.Regexp == nil && caseSensitive && !wholeWord {
+			continue
+		}
+		// 正则表达式已经编译过一次，加上标志后同样可以编译
+		q.patterns[t] = regexp.MustCompile(q.Pattern(t))
+	}
+	return q
+}
+
+// NewQuery 创建由 terms 按 combinator 组合而成的查询，搜索词按字面匹配，区分大小写，
+// 空的搜索词被忽略
+func NewQuery(combinator Combinator, terms ...string) *Query {
+	var nodes []Node
+	for _, term := range terms {
+		if term != "" {
+			nodes = append(nodes, &Term{Text: term})
+		}
+	}
+	return newQueryOf(combine(combinator, nodes), true, false)
+}
+
+// CaseSensitive 返回匹配时是否区分大小写
+func (q *Query) CaseSensitive() bool {
+	return q.caseSensitive
+}
+
+// WholeWord 返回搜索词是否只匹配完整的单词
+func (q *Query) WholeWord() bool {
+	return q.wholeWord
+}
+
+// Pattern 返回与搜索词 t 在本查询的大小写和整词设置下等价的 RE2 正则表达式，
+// 供支持正则表达式的后端使用；单词边界与 \b 相同，只把 ASCII 字母、数字和下划线视为单词字符
+func (q *Query) Pattern(t *Term) string {
+	pattern := regexp.QuoteMeta(t.Text)
+	if t.Regexp != nil {
+		pattern = "(?:" + t.Text + ")"
+	}
+	if q.wholeWord {
+		pattern = `\b` + pattern + `\b`
+	}
+	if !q.caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return pattern
+}
+
+// matchTerm 判断搜索词是否出现在 text 中
+func (q *Query) matchTerm(t *Term, text string) bool {
+	if re := q.patterns[t]; re != nil {
+		return re.MatchString(text)
+	}
+	return strings.Contains(text, t.Text)
+}
+
+// findTerm 返回搜索词在 text 中每一处不重叠出现的位置
+func (q *Query) findTerm(t *Term, text string) []Span {
+	re := q.patterns[t]
+	if re == nil {
+		return FindMatches(text, t.Text)
+	}
+	var spans []Span
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[0] < loc[1] {
+			spans = append(spans, Span{Start: loc[0], End: loc[1]})
+		}
+	}
+	return spans
+}
+
+// regexpPrefix 正则表达式搜索词的前缀
+const regexpPrefix = "re:"
+
+// ParseQuery 解析查询语句，如 `go AND (concurrency OR channel) NOT java`，
+// 得到的查询区分大小写，不要求整词匹配
+//
+// 搜索词以空白分隔，相邻的搜索词之间默认为 AND；
+// AND、OR、NOT 须大写，NOT 的优先级最高，AND 次之，OR 最低；
+// 括号用于分组，双引号括起的短语作为一个搜索词，短语中的 \" 表示引号；
+// 以 re: 开头的搜索词是正则表达式，如 re:go(lang)? 或 re:"new\s+york"，
+// 不加引号时表达式一直延续到下一个空白
+func ParseQuery(s string) (*Query, error) {
+	p := &queryParser{src: s, tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return newQueryOf(nil, true, false), nil
+	}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, p.errorf("unexpected %s", tok)
+	}
+	return newQueryOf(expr, true, false), nil
+}
+
+// combine 按 combinator 组合多个节点
+func combine(combinator Combinator, nodes []Node) Node {
+	switch {
+	case len(nodes) == 0:
+		return nil
+	case len(nodes) == 1:
+		return nodes[0]
+	case combinator == AnyTerm:
+		return &Or{Nodes: nodes}
+	}
+	return &And{Nodes: nodes}
+}
+
+// Expr 返回查询的语法树，空查询返回 nil
+func (q *Query) Expr() Node {
+	return q.expr
+}
+
+// Terms 返回查询中所有不在 NOT 之下、按字面匹配的搜索词，用于近似的后端查询
+// 正则表达式搜索词不包括在内
+func (q *Query) Terms() []string {
+	var terms []string
+	for _, t := range q.terms(true) {
+		if t.Regexp == nil {
+			terms = append(terms, t.Text)
+		}
+	}
+	return terms
+}
+
+// terms 返回查询中的所有搜索词，positive 为 true 时只返回不在 NOT 之下的搜索词
+func (q *Query) terms(positive bool) []*Term {
+	var terms []*Term
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *Term:
+			terms = append(terms, n)
+		case *And:
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *Or:
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *Not:
+			if !positive {
+				walk(n.Node)
+			}
+		}
+	}
+	walk(q.expr)
+	return terms
+}
+
+// Combinator 返回 Terms 的近似组合方式：查询只由 AND 连接时为 AllTerms，否则为 AnyTerm
+// 按 AnyTerm 查询得到的结果是查询结果的超集，后端不能精确翻译查询时可用 Match 再过滤
+func (q *Query) Combinator() Combinator {
+	var conjunction func(n Node) bool
+	conjunction = func(n Node) bool {
+		switch n := n.(type) {
+		case *Or:
+			return false
+		case *And:
+			for _, c := range n.Nodes {
+				if !conjunction(c) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if conjunction(q.expr) {
+		return AllTerms
+	}
+	return AnyTerm
+}
+
+// String 返回查询的文本形式，可以由 ParseQuery 重新解析
+func (q *Query) String() string {
+	if q.expr == nil {
+		return ""
+	}
+	return q.expr.String()
+}
+
+// Match 判断 text 是否满足查询，空查询不匹配任何文本
+func (q *Query) Match(text string) bool {
+	if q.expr == nil {
+		return false
+	}
+	return q.eval(q.expr, text)
+}
+
+// eval 计算节点对 text 是否匹配
+func (q *Query) eval(n Node, text string) bool {
+	switch n := n.(type) {
+	case *Term:
+		return q.matchTerm(n, text)
+	case *And:
+		for _, c := range n.Nodes {
+			if !q.eval(c, text) {
+				return false
+			}
+		}
+		return true
+	case *Or:
+		for _, c := range n.Nodes {
+			if q.eval(c, text) {
+				return true
+			}
+		}
+		return false
+	case *Not:
+		return !q.eval(n.Node, text)
+	}
+	return false
+}
+
+// Find 返回不在 NOT 之下的搜索词在 text 中出现的位置，按位置排序，重叠的只保留靠前的
+func (q *Query) Find(text string) []Span {
+	terms := q.terms(true)
+	var spans []Span
+	for _, term := range terms {
+		spans = append(spans, q.findTerm(term, text)...)
+	}
+	if len(terms) <= 1 {
+		return spans
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].End > spans[j].End
+	})
+	var merged []Span
+	for _, s := range spans {
+		if len(merged) > 0 && s.Start < merged[len(merged)-1].End {
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// token 查询语句的词法单元
+type token struct {
+	text   string
+	quoted bool
+	// regexp 是否为 re: 开头的正则表达式，text 中不含前缀
+	regexp bool
+}
+
+func (t token) String() string {
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keyword 返回 AND、OR、NOT 或括号，普通搜索词返回空字符串
+func keyword(s string) string {
+	switch s {
+	case "AND", "OR", "NOT", "(", ")":
+		return s
+	}
+	return ""
+}
+
+// tokenize 把查询语句切分为搜索词、短语、关键字和括号
+func tokenize(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			var text string
+			text, i = quoted(s, i)
+			tokens = append(tokens, token{text: text, quoted: true})
+		case strings.HasPrefix(s[i:], regexpPrefix):
+			// 正则表达式中可以有括号，一直延续到下一个空白
+			i += len(regexpPrefix)
+			if i < len(s) && s[i] == '"' {
+				var text string
+				text, i = quoted(s, i)
+				tokens = append(tokens, token{text: text, quoted: true, regexp: true})
+				continue
+			}
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\n\r", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: s[start:i], regexp: true})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\n\r()\"", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: s[start:i]})
+		}
+	}
+	return tokens
+}
+
+// quoted 读取 s[i] 处以双引号括起的短语，返回短语和其后的位置
+// 缺少结尾的引号时短语延续到语句末尾
+func quoted(s string, i int) (string, int) {
+	var b strings.Builder
+	i++
+	for i < len(s) && s[i] != '"' {
+		if s[i] == '\\' && i+1 < len(s) && s[i+1] == '"' {
+			i++
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String(), i + 1
+}
+
+// queryParser 递归下降的查询语句解析器
+type queryParser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// errorf 返回带有查询语句的解析错误
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query %q: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// peek 返回下一个词法单元
+func (p *queryParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// is 判断下一个词法单元是否为关键字 kw
+func (p *queryParser) is(kw string) bool {
+	tok, ok := p.peek()
+	return ok && !tok.quoted && !tok.regexp && tok.text == kw
+}
+
+// or := and { "OR" and }
+func (p *queryParser) or() (Node, error) {
+	var nodes []Node
+	for {
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if !p.is("OR") {
+			break
+		}
+		p.pos++
+	}
+	return combine(AnyTerm, nodes), nil
+}
+
+// and := unary { ["AND"] unary }
+func (p *queryParser) and() (Node, error) {
+	var nodes []Node
+	for {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if p.is("AND") {
+			p.pos++
+			continue
+		}
+		if _, ok := p.peek(); !ok || p.is("OR") || p.is(")") {
+			break
+		}
+	}
+	return combine(AllTerms, nodes), nil
+}
+
+// unary := "NOT" unary | "(" or ")" | term
+func (p *queryParser) unary() (Node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, p.errorf("unexpected end")
+	}
+	p.pos++
+	if tok.regexp {
+		return newRegexpTerm(tok.text)
+	}
+	if tok.quoted {
+		return &Term{Text: tok.text}, nil
+	}
+	switch tok.text {
+	case "NOT":
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Node: n}, nil
+	case "(":
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(")") {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return n, nil
+	case "AND", "OR", ")":
+		return nil, p.errorf("unexpected %s", tok)
+	}
+	return &Term{Text: tok.text}, nil
+}
+
+// newRegexpTerm 编译正则表达式搜索词
+func newRegexpTerm(expr string) (*Term, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("query: bad regexp %q: %v", expr, err)
+	}
+	return &Term{Text: expr, Regexp: re}, nil
+}
+
+// WithRegexp 把 Run 的搜索词整个作为一个正则表达式，不按查询语句解析
+// 只需要个别搜索词是正则表达式时，在查询语句中使用 re: 前缀
+func WithRegexp() Option {
+	return func(o *options) {
+		o.regexp = true
+	}
+}
+
+// WithCaseSensitive 设置匹配时是否区分大小写，默认区分
+func WithCaseSensitive(on bool) Option {
+	return func(o *options) {
+		o.caseSensitive = on
+	}
+}
+
+// WithWholeWord 设置搜索词是否只匹配完整的单词，如 go 不匹配 golang，默认不要求
+func WithWholeWord(on bool) Option {
+	return func(o *options) {
+		o.wholeWord = on
+	}
+}
+
+// WithTerms 在 Run 的搜索词之外增加按字面匹配的搜索词，默认要求所有搜索词都出现
+func WithTerms(terms ...string) Option {
+	return func(o *options) {
+		o.terms = append(o.terms, terms...)
+	}
+}
+
+// WithCombinator 设置 Run 的查询和 WithTerms 增加的搜索词之间的组合方式
+func WithCombinator(c Combinator) Option {
+	return func(o *options) {
+		o.combinator = c
+	}
+}
+
+// newQuery 解析 Run 的查询语句，并与额外的搜索词按 combinator 组合，再去掉停用词
+func newQuery(searchTerm string, o *options) (*Query, error) {
+	var q *Query
+	if o.regexp {
+		term, err := newRegexpTerm(searchTerm)
+		if err != nil {
+			return nil, err
+		}
+		q = newQueryOf(term, true, false)
+	} else {
+		var err error
+		if q, err = ParseQuery(searchTerm); err != nil {
+			return nil, err
+		}
+	}
+	var nodes []Node
+	if q.expr != nil {
+		nodes = append(nodes, q.expr)
+	}
+	for _, term := range o.terms {
+		if term != "" {
+			nodes = append(nodes, &Term{Text: term})
+		}
+	}
+	expr := removeStopWords(combine(o.combinator, nodes), o.language)
+	return newQueryOf(expr, o.caseSensitive, o.wholeWord), nil
+}
+
+// queryKey ctx 中保存 Query 的键
+type queryKey struct{}
+
+// NewQueryContext 返回携带查询 q 的 ctx
+func NewQueryContext(ctx context.Context, q *Query) context.Context {
+	return context.WithValue(ctx, queryKey{}, q)
+}
+
+// QueryFromContext 返回 ctx 中的查询
+// 匹配器不经 Run 直接调用时 ctx 中没有查询，此时返回按字面匹配 searchTerm 的查询
+func QueryFromContext(ctx context.Context, searchTerm string) *Query {
+	if q, ok := ctx.Value(queryKey{}).(*Query); ok {
+		return q
+	}
+	return NewQuery(AllTerms, searchTerm)
+}
@@ -0,0 +1,36 @@
+package search
+
+import (
+	"strings"
+)
+
+// TitleWeight 标题中出现的搜索词相对正文的权重
+var TitleWeight = 2.0
+
+// WithRanking 收集全部结果，按相关度从高到低显示，而不是按到达顺序，
+// 等同于 WithSort(SortByScore)
+func WithRanking() Option {
+	return WithSort(SortByScore)
+}
+
+// Rank 收集通道中的全部结果，为未打分的结果计算 Score，按得分从高到低依次发送
+func Rank(results <-chan *Result, query *Query) <-chan *Result {
+	return Sort(results, query, SortByScore)
+}
+
+// Score 计算结果的相关度：各个搜索词在内容中出现的次数之和，
+// 字段为标题或字段本身包含搜索词时按 TitleWeight 加权，再乘以数据源的权重
+func Score(result *Result, query *Query) float64 {
+	weight := 1.0
+	if strings.EqualFold(result.Field, "Title") {
+		weight = TitleWeight
+	}
+
+	score := float64(len(query.Find(result.Content))) * weight
+	score += float64(len(query.Find(result.Field))) * TitleWeight
+
+	if result.Feed != nil {
+		score *= result.Feed.weight()
+	}
+	return score
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// feedListTimeout 下载远程数据源列表的时限
+const feedListTimeout = 30 * time.Second
+
+// isRemote 判断数据源文件是否是 http(s) 地址
+func isRemote(location string) bool {
+	u, err := url.Parse(location)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// WithFeedCache 下载远程数据源列表后保存到 path，
+// 网络不可用时改为读取 path 中上一次下载的列表
+func WithFeedCache(path string) Option {
+	return func(o *options) {
+		o.feedCache = path
+	}
+}
+
+// FetchFeeds 下载并反序列化 uri 指向的数据源列表，格式按地址的扩展名识别
+// cache 不为空时，下载成功后将列表保存到 cache；下载失败时读取 cache
+func FetchFeeds(ctx context.Context, uri, cache string) ([]*Feed, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+
+	data, err := download(ctx, u)
+	if err != nil {
+		if cache == "" {
+			return nil, err
+		}
+		cached, cacheErr := os.ReadFile(cache)
+		if cacheErr != nil {
+			return nil, fmt.Errorf("%v (no cached copy: %v)", err, cacheErr)
+		}
+		slog.Warn("fetch feeds failed, using cached copy", "uri", u.Redacted(), "cache", cache, "error", err)
+		return decodeFeeds(bytes.NewReader(cached), ext)
+	}
+
+	feeds, err := decodeFeeds(bytes.NewReader(data), ext)
+	if err != nil {
+		return nil, err
+	}
+	// 只缓存能正确解码的列表；列表中可能有认证和选项的设置，只有当前用户可以读取
+	if cache != "" {
+		err := os.WriteFile(cache, data, 0600)
+		if err == nil {
+			// WriteFile 不改变已有文件的权限
+			err = os.Chmod(cache, 0600)
+		}
+		if err != nil {
+			slog.Warn("cache feeds", "path", cache, "error", err)
+		}
+	}
+	return feeds, nil
+}
+
+// download 下载 u 的内容，超过 MaxBodySize 时返回错误
+func download(ctx context.Context, u *url.URL) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, feedListTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := HTTPClient(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch feeds %s: HTTP response error %d", u.Redacted(), resp.StatusCode)
+	}
+	limit := MaxBodySize()
+	if limit <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	// 截断的列表无法解码，超过上限时直接报错
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		err = fmt.Errorf("fetch feeds %s: list larger than %d bytes", u.Redacted(), limit)
+	}
+	return data, err
+}
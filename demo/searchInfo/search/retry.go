@@ -0,0 +1,98 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 临时性错误的重试策略
+type RetryPolicy struct {
+	// Attempts 最多尝试的次数，1 表示不重试
+	Attempts int
+	// Backoff 第一次重试前的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// MaxBackoff 等待时间的上限
+	MaxBackoff time.Duration
+	// Jitter 等待时间随机浮动的比例，如 0.2 表示 ±20%
+	Jitter float64
+}
+
+// DefaultRetry 未指定 WithRetry 时使用的重试策略
+var DefaultRetry = RetryPolicy{
+	Attempts:   3,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+	Jitter:     0.2,
+}
+
+// WithRetry 设置临时性错误的重试策略
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// Retry 包装匹配器，搜索遇到临时性错误时按 policy 重试
+func Retry(matcher Matcher, policy RetryPolicy) Matcher {
+	if policy.Attempts <= 1 {
+		return matcher
+	}
+	return retryMatcher{matcher, policy}
+}
+
+// retryMatcher 带重试的匹配器
+type retryMatcher struct {
+	matcher Matcher
+	policy  RetryPolicy
+}
+
+// Search 调用被包装的匹配器，遇到临时性错误时等待后重试
+func (m retryMatcher) Search(ctx context.Context, feed *Feed, searchTerm string) ([]*Result, error) {
+	backoff := m.policy.Backoff
+	for attempt := 1; ; attempt++ {
+		results, err := m.matcher.Search(ctx, feed, searchTerm)
+		if err == nil || attempt >= m.policy.Attempts || !Transient(err) || ctx.Err() != nil {
+			return results, err
+		}
+
+		// 等待一段时间再重试，ctx 取消时立即返回
+		wait := backoff
+		if m.policy.Jitter > 0 {
+			wait += time.Duration((rand.Float64()*2 - 1) * m.policy.Jitter * float64(wait))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+
+		backoff *= 2
+		if m.policy.MaxBackoff > 0 && backoff > m.policy.MaxBackoff {
+			backoff = m.policy.MaxBackoff
+		}
+	}
+}
+
+// Transient 判断错误是否是临时性的，值得重试：
+// 网络超时、连接被拒绝或重置、响应被截断，以及实现了 Transient() bool
+// 并返回 true 的错误（如匹配器返回的 5xx 响应错误）
+func Transient(err error) bool {
+	var t interface{ Transient() bool }
+	if errors.As(err, &t) {
+		return t.Transient()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
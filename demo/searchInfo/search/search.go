@@ -1,63 +1,423 @@
 package search
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
 	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// registration 一个已注册的匹配器及其优先级
+type registration struct {
+	matcher  Matcher
+	priority int
+}
+
 // 注册用于搜索的匹配器的映射
-var matchers = make(map[string]Matcher)
+// 每个类型的匹配器按优先级从高到低排列
+var (
+	matchersMu sync.RWMutex
+	matchers   = make(map[string][]registration)
+)
+
+// Run 执行搜索，搜索的行为由 opts 配置，见以 With 开头的各个函数
+// 取消 ctx 可以中止正在进行的搜索，所有 goroutine 都会随之退出，
+// 已经得到的结果仍会交给 Displayer 输出，Run 在输出完后返回
+// 有数据源搜索失败时返回 Errors，其余数据源的结果仍会显示
+func Run(ctx context.Context, searchTerm string, opts ...Option) (err error) {
+	o := newOptions(opts)
+
+	ctx, span := o.tracer().Start(ctx, "search.Run", trace.WithAttributes(attribute.String("search.term", searchTerm)))
+	defer func() { endSpan(span, err) }()
+
+	// 解析查询语句，所有匹配器共用
+	query := o.query
+	if query == nil {
+		if query, err = newQuery(searchTerm, o); err != nil {
+			return err
+		}
+	}
+
+	// 达到结果数上限时用 cancel 取消仍在进行的匹配
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = NewQueryContext(ctx, query)
+
+	if o.clientFactory != nil {
+		ctx = context.WithValue(ctx, clientKey{}, o.clientFactory)
+	}
+	if o.metrics != nil {
+		ctx = context.WithValue(ctx, metricsKey{}, o.metrics)
+	}
+	if o.cache != nil {
+		ctx = context.WithValue(ctx, resultCacheKey{}, o.cache)
+	}
+
+	// 加载匹配器插件
+	if o.pluginDir != "" {
+		if err := LoadPlugins(o.pluginDir); err != nil {
+			return err
+		}
+	}
 
-// Run 执行搜索
-func Run(searchTerm string) {
 	// 获取需要搜索的数据源列表
-	feeds, err := RetrieveFeeds()
+	var feeds []*Feed
+	loadCtx, loadSpan := startSpan(ctx, "search.LoadFeeds", attribute.String("feeds.source", o.feedFile))
+	switch {
+	case o.watcher != nil:
+		feeds = o.watcher.Feeds()
+	case isRemote(o.feedFile):
+		feeds, err = FetchFeeds(loadCtx, o.feedFile, o.feedCache)
+	default:
+		feeds, err = LoadFeeds(o.feedFile)
+	}
+	loadSpan.SetAttributes(attribute.Int("feeds.count", len(feeds)))
+	endSpan(loadSpan, err)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	// 检查数据源列表，警告只记录日志，有错误时不搜索
+	problems := Validate(feeds)
+	for _, p := range problems {
+		if p.Warning {
+			slog.Warn("feed problem", "index", p.Index, "field", p.Field, "problem", p.Msg)
+		}
+	}
+	if err := problems.Err(); err != nil {
+		return err
+	}
+
+	// 按类型和标签筛选数据源
+	var selected []*Feed
+	for _, feed := range feeds {
+		if o.selected(feed) {
+			selected = append(selected, feed)
+		}
 	}
+	feeds = selected
 
-	// 创建一个无缓冲的通道，接受匹配后的结果
-	results := make(chan *Result)
+	// 报告搜索进度
+	progress := newTracker(len(feeds), o.progress)
+
+	// 匹配器把一个数据源的结果成批发送到 batches，每个数据源只需一次通道操作；
+	// 再由一个 goroutine 逐个转发到带缓冲的 results，生产者之间不必相互等待
+	batches := make(chan []*Result, o.buffer)
+	results := make(chan *Result, o.buffer)
 
 	// 构造一个waitGroup，处理所有的数据源
 	var waitGroup sync.WaitGroup
 
-	// 设置需要等待处理
-	// 每个数据源的goroutine数量
-	waitGroup.Add(len(feeds))
+	// 收集搜索失败的数据源
+	var (
+		mu   sync.Mutex
+		errs Errors
+	)
 
-	// 为每个数据源启动goroutine并行查找
-	for _, feed := range feeds {
-		// 获取数据源的匹配器用于查找
-		matcher, exists := matchers[feed.Type]
-		if !exists {
-			matcher = matchers["default"]
+	// 所有匹配器都经过中间件，安装的中间件在本次搜索中保持不变
+	wrap := o.chain()
+
+	// match 使用数据源的匹配器查找
+	// 默认只用优先级最高的匹配器，扇出模式下所有匹配器同时查找并合并结果
+	// canceled 记录因搜索取消而没有开始的数据源，与失败的数据源一样计入错误、指标和进度
+	canceled := func(feed *Feed) {
+		err := &FeedError{Feed: feed, Err: ctx.Err()}
+		mu.Lock()
+		o.metrics.observe(feed, 0, 0, err)
+		errs = append(errs, err)
+		mu.Unlock()
+		progress.finish(feed, err)
+	}
+
+	match := func(feed *Feed) {
+		// 搜索已经取消（例如结果数达到了上限）时不再开始新的数据源，
+		// 以免对大量数据源逐个发起注定被放弃的搜索
+		if ctx.Err() != nil {
+			canceled(feed)
+			return
+		}
+		found := lookup(feed.Type)
+		if len(found) == 0 {
+			mu.Lock()
+			err := &FeedError{Feed: feed, Err: fmt.Errorf("%w for type %q", errNoMatcher, feed.Type)}
+			o.metrics.observe(feed, 0, 0, err)
+			errs = append(errs, err)
+			mu.Unlock()
+			progress.finish(feed, err)
+			return
+		}
+		if !o.fanOut {
+			found = found[:1]
+		}
+		max := o.feedMaxResults
+		if feed.MaxResults > 0 {
+			max = feed.MaxResults
 		}
 
-		// 启动一个goroutine查询
-		go func(matcher Matcher, feed *Feed) {
-			Match(matcher, feed, searchTerm, results)
-			defer waitGroup.Done()
-		}(matcher, feed)
+		// failed 记录该数据源的第一个错误，扇出模式下任一匹配器失败即算失败
+		var (
+			wg     sync.WaitGroup
+			failed error
+		)
+		wg.Add(len(found))
+		for _, matcher := range found {
+			go func(matcher Matcher) {
+				defer wg.Done()
+				searchResults, err := o.matchQueries(ctx, wrap(matcher), feed, searchTerm, max)
+				if err == nil && len(searchResults) > 0 {
+					// 已经得到的结果即使搜索被取消也照常交给后面，转发的 goroutine 会取走所有批次
+					batches <- searchResults
+				}
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err.(*FeedError))
+					if failed == nil {
+						failed = err
+					}
+					mu.Unlock()
+				}
+			}(matcher)
+		}
+		wg.Wait()
+		progress.finish(feed, failed)
+	}
+
+	if o.workers > 0 {
+		// 工作池：固定数量的goroutine依次处理数据源
+		jobs := make(chan *Feed)
+		waitGroup.Add(o.workers)
+		for i := 0; i < o.workers; i++ {
+			go func() {
+				defer waitGroup.Done()
+				for feed := range jobs {
+					match(feed)
+				}
+			}()
+		}
+		go func() {
+			defer close(jobs)
+			for i, feed := range feeds {
+				select {
+				case jobs <- feed:
+				case <-ctx.Done():
+					// 剩下的数据源不再交给工作池，但仍要记录，进度的总数才能对上
+					for _, feed := range feeds[i:] {
+						canceled(feed)
+					}
+					return
+				}
+			}
+		}()
+	} else {
+		// 设置需要等待处理
+		// 每个数据源的goroutine数量
+		waitGroup.Add(len(feeds))
+
+		// 为每个数据源启动goroutine并行查找
+		for _, feed := range feeds {
+			// 启动一个goroutine查询
+			go func(feed *Feed) {
+				defer waitGroup.Done()
+				match(feed)
+			}(feed)
+		}
 	}
 
 	// 启动一个goroutine来监控是否所以得工作都完成了
 	go func() {
 		// 等候所有任务完成
 		waitGroup.Wait()
-		// 关闭通道，通知Display函数
-		close(results)
+		// 关闭通道，通知转发的goroutine
+		close(batches)
 	}()
 
+	// 把成批的结果逐个转发给后面的处理阶段，全部转发后关闭通道，通知Display函数
+	go func() {
+		defer close(results)
+		// 搜索取消后仍转发已经得到的结果，让 Display 输出部分结果后再返回
+		for batch := range batches {
+			for _, result := range batch {
+				results <- result
+			}
+		}
+	}()
+
+	// 去除重复的结果
+	var out <-chan *Result = results
+	if o.dedup {
+		out = Dedup(out, o.dedupSources)
+	}
+
+	// 只显示没有见过的结果
+	var fresh *newOnlyStage
+	if o.seen != nil {
+		fresh = newOnly(o.seen)
+		out = fresh.filter(out)
+	}
+
+	// 结果数上限、排序、摘要和分页
+	out = o.arrange(out, query, cancel)
+
+	// 收集模式下全部结果到齐后再显示
+	if o.mode == Collected {
+		out = Collect(out)
+	}
+
+	// 显示成功后，显示程序取走的结果才算见过，被上限或分页丢弃的结果下次仍然是新的
+	if fresh != nil {
+		out = fresh.mark(out)
+	}
+
 	// 显示返回结果
-	Display(results)
+	displayer := o.displayer
+	if displayer == nil {
+		displayer = &PlainDisplayer{W: o.output, Markers: o.markers}
+	}
+	_, displaySpan := startSpan(ctx, "search.Display", attribute.String("display.type", fmt.Sprintf("%T", displayer)))
+	err = displayer.Display(out)
+	endSpan(displaySpan, err)
+	if err != nil {
+		// 取消仍在进行的匹配，再排空通道，让仍在发送结果的 goroutine 退出，
+		// 否则要等所有数据源都搜索完 Run 才能返回
+		// 显示失败时结果不算见过，下次搜索仍会报告
+		cancel()
+		for range out {
+		}
+		return err
+	}
+	if fresh != nil {
+		fresh.commit()
+	}
+
+	// 因结果数达到上限而取消的匹配不算失败
+	if ctx.Err() != nil && parent.Err() == nil {
+		var failed Errors
+		for _, err := range errs {
+			if !errors.Is(err, context.Canceled) {
+				failed = append(failed, err)
+			}
+		}
+		errs = failed
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
 
 // Register 调用时，会注册一个匹配器，提供给后面的程序使用
-func Register(feedType string, matcher Matcher) {
-	if _, exists := matchers[feedType]; exists {
-		log.Fatalln(feedType, "Matcher already registered")
+// 匹配器的优先级为 0，同一类型重复注册时返回错误
+func Register(feedType string, matcher Matcher) error {
+	return RegisterPriority(feedType, matcher, 0)
+}
+
+// RegisterPriority 以指定优先级注册匹配器
+// 同一类型可以注册多个优先级不同的匹配器，如快速的缓存匹配器和较慢的实时匹配器
+// 同一类型、同一优先级重复注册时返回错误
+func RegisterPriority(feedType string, matcher Matcher, priority int) error {
+	matchersMu.Lock()
+	defer matchersMu.Unlock()
+
+	registered := matchers[feedType]
+	i := 0
+	for ; i < len(registered); i++ {
+		if registered[i].priority == priority {
+			return fmt.Errorf("%s matcher with priority %d already registered", feedType, priority)
+		}
+		if registered[i].priority < priority {
+			break
+		}
+	}
+	slog.Debug("register matcher", "type", feedType)
+	registered = append(registered, registration{})
+	copy(registered[i+1:], registered[i:])
+	registered[i] = registration{matcher, priority}
+	matchers[feedType] = registered
+	return nil
+}
+
+// MustRegister 与 Register 相同，但注册失败时 panic，供 init 函数使用
+func MustRegister(feedType string, matcher Matcher) {
+	if err := Register(feedType, matcher); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister 移除某个类型的所有匹配器，该类型未注册时返回错误
+func Unregister(feedType string) error {
+	matchersMu.Lock()
+	defer matchersMu.Unlock()
+
+	if _, exists := matchers[feedType]; !exists {
+		return fmt.Errorf("%s matcher not registered", feedType)
 	}
-	log.Println("Register", feedType, "matcher")
-	matchers[feedType] = matcher
+	slog.Debug("unregister matcher", "type", feedType)
+	delete(matchers, feedType)
+	return nil
+}
+
+// Replace 注册匹配器，替换该类型已有的所有匹配器
+// 返回被替换的优先级最高的匹配器，之前未注册时返回 nil
+func Replace(feedType string, matcher Matcher) Matcher {
+	matchersMu.Lock()
+	defer matchersMu.Unlock()
+
+	var previous Matcher
+	if registered := matchers[feedType]; len(registered) > 0 {
+		previous = registered[0].matcher
+	}
+	slog.Debug("replace matcher", "type", feedType)
+	matchers[feedType] = []registration{{matcher: matcher}}
+	return previous
+}
+
+// lookup 按优先级从高到低返回处理该类型数据源的匹配器
+// 未注册的类型使用默认匹配器
+func lookup(feedType string) []Matcher {
+	matchersMu.RLock()
+	defer matchersMu.RUnlock()
+
+	registered, exists := matchers[feedType]
+	if !exists {
+		registered = matchers["default"]
+	}
+	found := make([]Matcher, len(registered))
+	for i, r := range registered {
+		found[i] = r.matcher
+	}
+	return found
+}
+
+// Registration 已注册的匹配器
+type Registration struct {
+	Type     string
+	Priority int
+	Matcher  Matcher
+}
+
+// Registered 返回所有已注册的匹配器，按类型排序，同一类型按优先级从高到低
+func Registered() []Registration {
+	matchersMu.RLock()
+	defer matchersMu.RUnlock()
+
+	var list []Registration
+	for feedType, registered := range matchers {
+		for _, r := range registered {
+			list = append(list, Registration{Type: feedType, Priority: r.priority, Matcher: r.matcher})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Type != list[j].Type {
+			return list[i].Type < list[j].Type
+		}
+		return list[i].Priority > list[j].Priority
+	})
+	return list
 }
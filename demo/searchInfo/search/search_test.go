@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchMatcher 立即返回固定数量的结果，模拟很快的数据源
+type benchMatcher struct{ n int }
+
+func (m benchMatcher) Search(ctx context.Context, feed *Feed, searchTerm string) ([]*Result, error) {
+	results := make([]*Result, m.n)
+	for i := range results {
+		results[i] = &Result{
+			Field:   fmt.Sprintf("%s #%d", feed.Name, i),
+			Content: "golang " + feed.Name,
+			URL:     fmt.Sprintf("https://example.com/%s/%d", feed.Name, i),
+		}
+	}
+	return results, nil
+}
+
+// benchFeeds 注册 bench 匹配器，写入 n 个该类型的数据源，返回数据源文件的路径
+func benchFeeds(b *testing.B, n, results int) string {
+	b.Helper()
+	Unregister("bench")
+	MustRegister("bench", benchMatcher{results})
+	b.Cleanup(func() { Unregister("bench") })
+
+	feeds := make([]*Feed, n)
+	for i := range feeds {
+		feeds[i] = &Feed{Name: fmt.Sprintf("feed%d", i), URI: fmt.Sprintf("bench://%d", i), Type: "bench"}
+	}
+	data, err := json.Marshal(feeds)
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := filepath.Join(b.TempDir(), "feeds.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// BenchmarkRun 测量很多快速数据源时整个搜索的吞吐量，比较结果通道不同的缓冲区大小
+func BenchmarkRun(b *testing.B) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	feedFile := benchFeeds(b, 200, 20)
+	for _, buffer := range []int{0, 16, DefaultBuffer, 256} {
+		b.Run(fmt.Sprintf("buffer=%d", buffer), func(b *testing.B) {
+			opts := []Option{WithFeedsFile(feedFile), WithBuffer(buffer), WithWorkers(8), WithOutput(io.Discard)}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := Run(context.Background(), "golang", opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRunDedup 与 BenchmarkRun 相同，但结果经过去重阶段
+func BenchmarkRunDedup(b *testing.B) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	feedFile := benchFeeds(b, 200, 20)
+	opts := []Option{WithFeedsFile(feedFile), WithWorkers(8), WithOutput(io.Discard), WithDedup(false)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Run(context.Background(), "golang", opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"unicode/utf8"
+)
+
+// Ellipsis 摘要被截断的一侧所加的省略号
+const Ellipsis = "…"
+
+// WithSnippets 显示前把较长的内容截成以第一处匹配为中心、
+// 长度为 width 个字符的摘要，被截掉的一侧加省略号
+func WithSnippets(width int) Option {
+	return func(o *options) {
+		o.snippet = width
+	}
+}
+
+// Snippets 把通道中每个结果的内容截成摘要后发送，见 Snippet
+func Snippets(results <-chan *Result, width int) <-chan *Result {
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		for result := range results {
+			Snippet(result, width)
+			out <- result
+		}
+	}()
+	return out
+}
+
+// Snippet 把结果的内容截成以第一处匹配为中心、长度为 width 个字符的摘要，
+// 并调整 Matches 中的位置，只保留摘要内的匹配
+// 没有匹配时保留开头部分；内容不超过 width 个字符时不做改动
+func Snippet(result *Result, width int) {
+	content := result.Content
+	n := utf8.RuneCountInString(content)
+	if width <= 0 || n <= width {
+		return
+	}
+
+	// 以字符为单位确定窗口
+	start := 0
+	if len(result.Matches) > 0 {
+		first := result.Matches[0]
+		matchStart := utf8.RuneCountInString(content[:first.Start])
+		matchLen := utf8.RuneCountInString(content[first.Start:first.End])
+		start = matchStart - (width-matchLen)/2
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + width
+	if end > n {
+		end = n
+		start = end - width
+	}
+
+	// 换算为字节位置
+	from, to := byteOffset(content, start), byteOffset(content, end)
+
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = Ellipsis
+	}
+	if to < len(content) {
+		suffix = Ellipsis
+	}
+	result.Content = prefix + content[from:to] + suffix
+
+	var matches []Span
+	for _, s := range result.Matches {
+		if s.Start >= from && s.End <= to {
+			shift := len(prefix) - from
+			matches = append(matches, Span{Start: s.Start + shift, End: s.End + shift})
+		}
+	}
+	result.Matches = matches
+}
+
+// byteOffset 返回 s 中第 runes 个字符的字节位置
+func byteOffset(s string, runes int) int {
+	for i := range s {
+		if runes == 0 {
+			return i
+		}
+		runes--
+	}
+	return len(s)
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey 结果的排序依据
+type SortKey int
+
+const (
+	// SortByFeed 按数据源名称升序
+	SortByFeed SortKey = iota + 1
+	// SortByField 按字段升序
+	SortByField
+	// SortByScore 按相关度得分降序
+	SortByScore
+	// SortByTime 按发布时间降序，没有时间的结果排在最后
+	SortByTime
+)
+
+var sortKeyNames = map[SortKey]string{
+	SortByFeed:  "feed",
+	SortByField: "field",
+	SortByScore: "score",
+	SortByTime:  "time",
+}
+
+func (k SortKey) String() string {
+	if name, ok := sortKeyNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("SortKey(%d)", int(k))
+}
+
+// ParseSortKey 解析排序依据的名称：feed、field、score 或 time
+func ParseSortKey(name string) (SortKey, error) {
+	for k, n := range sortKeyNames {
+		if strings.EqualFold(n, name) {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sort key %q", name)
+}
+
+// WithSort 收集全部结果，依次按 keys 排序后再显示，
+// 使多次运行的输出顺序一致，便于比较
+func WithSort(keys ...SortKey) Option {
+	return func(o *options) {
+		o.sort = append(o.sort, keys...)
+	}
+}
+
+// Sort 收集通道中的全部结果，依次按 keys 排序后发送
+// 所有 keys 都相同时再按数据源、字段、地址和内容排序，使顺序与到达顺序无关
+// 按得分排序时为未打分的结果计算 Score
+func Sort(results <-chan *Result, query *Query, keys ...SortKey) <-chan *Result {
+	scored := false
+	for _, key := range keys {
+		if key == SortByScore {
+			scored = true
+		}
+	}
+	keys = append(keys, SortByFeed, SortByField)
+
+	out := make(chan *Result)
+	go func() {
+		defer close(out)
+		var sorted []*Result
+		for result := range results {
+			if scored && result.Score == 0 {
+				result.Score = Score(result, query)
+			}
+			sorted = append(sorted, result)
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := sorted[i], sorted[j]
+			for _, key := range keys {
+				if c := compareResults(a, b, key); c != 0 {
+					return c < 0
+				}
+			}
+			if a.URL != b.URL {
+				return a.URL < b.URL
+			}
+			return a.Content < b.Content
+		})
+		for _, result := range sorted {
+			out <- result
+		}
+	}()
+	return out
+}
+
+// compareResults 按 key 比较两个结果，a 应排在前面时返回负数
+func compareResults(a, b *Result, key SortKey) int {
+	switch key {
+	case SortByFeed:
+		return strings.Compare(feedName(a), feedName(b))
+	case SortByField:
+		return strings.Compare(a.Field, b.Field)
+	case SortByScore:
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+	case SortByTime:
+		switch {
+		case a.Time.IsZero() != b.Time.IsZero():
+			if a.Time.IsZero() {
+				return 1
+			}
+			return -1
+		case a.Time.After(b.Time):
+			return -1
+		case a.Time.Before(b.Time):
+			return 1
+		}
+	}
+	return 0
+}
+
+// feedName 返回产生结果的数据源名称
+func feedName(result *Result) string {
+	if result.Feed == nil {
+		return ""
+	}
+	return result.Feed.Name
+}
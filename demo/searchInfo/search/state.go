@@ -0,0 +1,115 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SeenRetention 状态文件中的结果在这么久没有再见到后被删除，
+// 避免状态文件无限增长，0 表示一直保留
+var SeenRetention = 90 * 24 * time.Hour
+
+// stateFile 状态文件的内容：每个搜索词在各个数据源见过的结果，
+// 键为结果的哈希，值为最后一次见到的时间
+type stateFile struct {
+	Searches map[string]map[string]map[string]time.Time `json:"searches"`
+}
+
+// DefaultStatePath 返回用户配置目录下的状态文件路径
+func DefaultStatePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "searchInfo", "state.json")
+}
+
+// LoadSeenSet 从状态文件 path 读取搜索词 searchTerm 上次搜索时见过的结果，
+// 文件不存在或没有这个搜索词时返回空的 SeenSet。配合 WithNewOnly 使用，
+// 搜索后调用 Save 写回，下次搜索就只报告这期间新出现的结果
+func LoadSeenSet(path, searchTerm string) (*SeenSet, error) {
+	state, err := readState(path)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSeenSet()
+	s.path, s.searchTerm = path, searchTerm
+	for feed, keys := range state.Searches[searchTerm] {
+		s.feeds[feed] = keys
+	}
+	return s, nil
+}
+
+// Save 把见过的结果写回 LoadSeenSet 读取的状态文件，文件中其他搜索词的记录不变；
+// 超过 SeenRetention 没有再见到的结果不再保存。NewSeenSet 创建的 SeenSet 不保存
+// 先写入临时文件再改名，写入失败时不会破坏原文件
+func (s *SeenSet) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	state, err := readState(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	feeds := make(map[string]map[string]time.Time, len(s.feeds))
+	for feed, keys := range s.feeds {
+		kept := make(map[string]time.Time, len(keys))
+		for key, last := range keys {
+			if SeenRetention <= 0 || time.Since(last) < SeenRetention {
+				kept[key] = last
+			}
+		}
+		if len(kept) > 0 {
+			feeds[feed] = kept
+		}
+	}
+	s.mu.Unlock()
+	state.Searches[s.searchTerm] = feeds
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// readState 读取状态文件，文件不存在时返回空的状态
+func readState(path string) (*stateFile, error) {
+	state := &stateFile{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if state.Searches == nil {
+		state.Searches = make(map[string]map[string]map[string]time.Time)
+	}
+	return state, nil
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage 默认使用的停用词语言
+const DefaultLanguage = "en"
+
+// stopWords 各语言的停用词表，键为语言代码
+var (
+	stopWordsMu sync.RWMutex
+	stopWords   = map[string]map[string]bool{
+		"en": wordSet(
+			"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+			"has", "have", "he", "her", "his", "i", "if", "in", "into", "is", "it",
+			"its", "me", "my", "no", "not", "of", "on", "or", "our", "she", "so",
+			"such", "that", "the", "their", "them", "then", "there", "these", "they",
+			"this", "to", "was", "we", "were", "what", "when", "which", "who", "will",
+			"with", "you", "your",
+		),
+		"zh": wordSet(
+			"的", "了", "和", "是", "在", "就", "都", "而", "及", "与", "着", "或",
+			"一个", "没有", "我们", "你们", "他们", "这", "那", "之", "也", "很",
+		),
+	}
+)
+
+// wordSet 把单词列表转换为集合，单词转换为小写
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[strings.ToLower(w)] = true
+	}
+	return set
+}
+
+// SetStopWords 设置语言 lang 的停用词表，代替内置的停用词表；words 为空时该语言不过滤停用词
+func SetStopWords(lang string, words ...string) {
+	stopWordsMu.Lock()
+	defer stopWordsMu.Unlock()
+	stopWords[lang] = wordSet(words...)
+}
+
+// IsStopWord 判断 word 是否为语言 lang 的停用词，不区分大小写
+func IsStopWord(lang, word string) bool {
+	stopWordsMu.RLock()
+	defer stopWordsMu.RUnlock()
+	return stopWords[lang][strings.ToLower(word)]
+}
+
+// WithStopWords 按语言 lang 的停用词表过滤查询中的停用词，默认为 DefaultLanguage；
+// lang 为空时不过滤
+func WithStopWords(lang string) Option {
+	return func(o *options) {
+		o.language = lang
+	}
+}
+
+// removeStopWords 从有多个搜索词的查询中去掉停用词
+// 只去掉按字面匹配的单个单词，短语和正则表达式保留；
+// 所有搜索词都是停用词时保留原查询，否则搜索 "the who" 之类的查询将什么也找不到
+func removeStopWords(expr Node, lang string) Node {
+	if lang == "" {
+		return expr
+	}
+	q := &Query{expr: expr}
+	terms := q.terms(false)
+	if len(terms) < 2 {
+		return expr
+	}
+	stop := func(t *Term) bool {
+		return t.Regexp == nil && !strings.ContainsAny(t.Text, " \t\n") && IsStopWord(lang, t.Text)
+	}
+	kept := 0
+	for _, t := range terms {
+		if !stop(t) {
+			kept++
+		}
+	}
+	if kept == 0 || kept == len(terms) {
+		return expr
+	}
+
+	var prune func(n Node) Node
+	prune = func(n Node) Node {
+		switch n := n.(type) {
+		case *Term:
+			if stop(n) {
+				return nil
+			}
+			return n
+		case *And:
+			return combine(AllTerms, pruneAll(n.Nodes, prune))
+		case *Or:
+			return combine(AnyTerm, pruneAll(n.Nodes, prune))
+		case *Not:
+			if c := prune(n.Node); c != nil {
+				return &Not{Node: c}
+			}
+		}
+		return nil
+	}
+	return prune(expr)
+}
+
+// pruneAll 对每个节点调用 prune，去掉结果为空的节点
+func pruneAll(nodes []Node, prune func(Node) Node) []Node {
+	var kept []Node
+	for _, n := range nodes {
+		if c := prune(n); c != nil {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
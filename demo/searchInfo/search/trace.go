@@ -0,0 +1,62 @@
+package search
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 本包创建的 span 所属的 Tracer 名称
+const tracerName = "github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+
+// Run 的每次搜索创建以下 span，嵌入的程序配置好 OpenTelemetry 后即可看到：
+//
+//	search.Run          整个搜索
+//	├─ search.LoadFeeds 获取数据源列表
+//	├─ search.Feed      每个匹配器对每个数据源的搜索，并发进行
+//	└─ search.Display   显示结果，与各数据源的搜索同时进行
+//
+// 没有配置时 otel 的默认 TracerProvider 什么也不做
+
+// WithTracerProvider 使用 tp 创建 span，默认使用 otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// tracer 返回创建 Run 的 span 所用的 Tracer
+func (o *options) tracer() trace.Tracer {
+	tp := o.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startSpan 创建 ctx 中 span 的子 span，使用与它相同的 TracerProvider
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan 结束 span，err 不为空时记录错误
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// feedAttributes 返回描述数据源的 span 属性
+func feedAttributes(feed *Feed) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("feed.name", feed.Name),
+		attribute.String("feed.type", feed.Type),
+		attribute.String("feed.uri", feed.RedactedURI()),
+	}
+}
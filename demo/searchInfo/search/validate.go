@@ -0,0 +1,126 @@
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// FeedProblem 数据源列表中某一项的问题
+type FeedProblem struct {
+	// Index 出问题的数据源在列表中的下标
+	Index int
+	// Field 出问题的字段，使用数据源文件中的字段名
+	Field string
+	Msg   string
+	// Warning 为 true 时只是警告，数据源仍然可以搜索
+	Warning bool
+}
+
+func (p FeedProblem) Error() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("feed #%d %s: %s: %s", p.Index, p.Field, level, p.Msg)
+}
+
+// FeedProblems 是 Validate 的结果
+type FeedProblems []FeedProblem
+
+func (ps FeedProblems) Error() string {
+	msgs := make([]string, len(ps))
+	for i, p := range ps {
+		msgs[i] = p.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Err 返回其中的错误（不含警告），没有错误时返回 nil
+func (ps FeedProblems) Err() error {
+	var errs FeedProblems
+	for _, p := range ps {
+		if !p.Warning {
+			errs = append(errs, p)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Validate 检查解码后的数据源列表：名称为空、地址格式错误、
+// 时限无法解析、权重或结果数为负、认证设置不完整和重复的数据源是错误，
+// 未注册的类型和认证所需的环境变量未设置是警告
+func Validate(feeds []*Feed) FeedProblems {
+	var ps FeedProblems
+	report := func(i int, field string, warning bool, format string, args ...interface{}) {
+		ps = append(ps, FeedProblem{
+			Index:   i,
+			Field:   field,
+			Msg:     fmt.Sprintf(format, args...),
+			Warning: warning,
+		})
+	}
+
+	seen := make(map[[2]string]int)
+	for i, feed := range feeds {
+		if feed == nil {
+			report(i, "", false, "empty entry")
+			continue
+		}
+
+		if strings.TrimSpace(feed.Name) == "" {
+			report(i, "site", false, "empty name")
+		}
+
+		if feed.URI == "" {
+			report(i, "link", false, "empty link")
+		} else if u, err := url.Parse(feed.URI); err != nil {
+			report(i, "link", false, "malformed link: %v", err)
+		} else if (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+			report(i, "link", false, "malformed link: no host")
+		}
+
+		matchersMu.RLock()
+		_, registered := matchers[feed.Type]
+		matchersMu.RUnlock()
+		if !registered {
+			report(i, "type", true, "unknown type %q, using the default matcher", feed.Type)
+		}
+
+		if _, err := feed.timeout(DefaultTimeout); err != nil {
+			report(i, "timeout", false, "%v", err)
+		}
+
+		if feed.Weight < 0 {
+			report(i, "weight", false, "negative weight %v", feed.Weight)
+		}
+
+		if feed.MaxResults < 0 {
+			report(i, "max_results", false, "negative max_results %d", feed.MaxResults)
+		}
+
+		if feed.Auth != nil {
+			if err := feed.Auth.validate(); err != nil {
+				report(i, "auth", false, "%v", err)
+			} else {
+				for _, env := range []string{feed.Auth.UsernameEnv, feed.Auth.TokenEnv} {
+					if env != "" && os.Getenv(env) == "" {
+						report(i, "auth", true, "environment variable %s is not set", env)
+					}
+				}
+			}
+		}
+
+		key := [2]string{feed.Type, feed.URI}
+		if first, dup := seen[key]; dup {
+			report(i, "link", false, "duplicate of feed #%d", first)
+		} else {
+			seen[key] = i
+		}
+	}
+	return ps
+}
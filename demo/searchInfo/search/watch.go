@@ -0,0 +1,120 @@
+package search
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay 文件变化后等待的时间，合并编辑器保存时产生的多个事件
+const reloadDelay = 100 * time.Millisecond
+
+// FeedWatcher 监控数据源文件，文件变化时自动重新加载
+// 长时间运行的程序每次搜索前调用 Feeds 即可用上最新的数据源
+type FeedWatcher struct {
+	path    string
+	watcher *fsnotify.Watcher
+
+	mu    sync.RWMutex
+	feeds []*Feed
+}
+
+// WatchFeeds 加载数据源文件 path 并开始监控它
+// 重新加载失败时保留原来的数据源列表，并记录日志
+func WatchFeeds(path string) (*FeedWatcher, error) {
+	feeds, err := loadValid(path)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// 监控所在目录而不是文件本身，编辑器常用重命名的方式保存文件
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w := &FeedWatcher{path: path, watcher: watcher, feeds: feeds}
+	go w.run()
+	return w, nil
+}
+
+// Feeds 返回当前的数据源列表
+func (w *FeedWatcher) Feeds() []*Feed {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.feeds
+}
+
+// Close 停止监控
+func (w *FeedWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// run 处理文件事件，直到 Close 被调用
+func (w *FeedWatcher) run() {
+	name := filepath.Clean(w.path)
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if filepath.Clean(event.Name) != name || event.Has(fsnotify.Chmod) {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(reloadDelay, w.reload)
+			} else {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("watch feeds", "error", err)
+		}
+	}
+}
+
+// reload 重新加载数据源文件
+func (w *FeedWatcher) reload() {
+	feeds, err := loadValid(w.path)
+	if err != nil {
+		slog.Error("reload feeds", "path", w.path, "error", err)
+		return
+	}
+	w.mu.Lock()
+	w.feeds = feeds
+	w.mu.Unlock()
+	slog.Info("reload feeds", "path", w.path, "feeds", len(feeds))
+}
+
+// WithWatcher 从 w 获取数据源列表，代替每次读取数据源文件
+func WithWatcher(w *FeedWatcher) Option {
+	return func(o *options) {
+		o.watcher = w
+	}
+}
+
+// loadValid 读取数据源文件并检查，有错误时返回错误
+func loadValid(path string) ([]*Feed, error) {
+	feeds, err := LoadFeeds(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(feeds).Err(); err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
@@ -0,0 +1,497 @@
+// SearchService 以 gRPC 提供搜索，结果以服务端流的形式在到达时逐个返回。
+//
+// 修改后重新生成 Go 代码：
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	       --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	       searchpb/search.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: searchpb/search.proto
+
+package searchpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SearchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// query 搜索词，语法与命令行相同
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// max_results 大于 0 时得到这么多结果后停止搜索
+	MaxResults int32 `protobuf:"varint,2,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	// tags 只搜索带有其中任一标签的数据源
+	Tags []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	// types 只搜索这些类型的数据源
+	Types         []string `protobuf:"bytes,4,rep,name=types,proto3" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_searchpb_search_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_searchpb_search_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_searchpb_search_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type SearchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SearchResponse_Result
+	//	*SearchResponse_FeedError
+	Event         isSearchResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_searchpb_search_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_searchpb_search_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_searchpb_search_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchResponse) GetEvent() isSearchResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetResult() *Result {
+	if x != nil {
+		if x, ok := x.Event.(*SearchResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetFeedError() *FeedError {
+	if x != nil {
+		if x, ok := x.Event.(*SearchResponse_FeedError); ok {
+			return x.FeedError
+		}
+	}
+	return nil
+}
+
+type isSearchResponse_Event interface {
+	isSearchResponse_Event()
+}
+
+type SearchResponse_Result struct {
+	Result *Result `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type SearchResponse_FeedError struct {
+	FeedError *FeedError `protobuf:"bytes,2,opt,name=feed_error,json=feedError,proto3,oneof"`
+}
+
+func (*SearchResponse_Result) isSearchResponse_Event() {}
+
+func (*SearchResponse_FeedError) isSearchResponse_Event() {}
+
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Feed          string                 `protobuf:"bytes,1,opt,name=feed,proto3" json:"feed,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Field         string                 `protobuf:"bytes,3,opt,name=field,proto3" json:"field,omitempty"`
+	Content       string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	Url           string                 `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+	Score         float64                `protobuf:"fixed64,7,opt,name=score,proto3" json:"score,omitempty"`
+	Matches       []*Span                `protobuf:"bytes,8,rep,name=matches,proto3" json:"matches,omitempty"`
+	Sources       []string               `protobuf:"bytes,9,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_searchpb_search_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_searchpb_search_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_searchpb_search_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Result) GetFeed() string {
+	if x != nil {
+		return x.Feed
+	}
+	return ""
+}
+
+func (x *Result) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Result) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *Result) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Result) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Result) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Result) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Result) GetMatches() []*Span {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *Result) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+// Span content 中匹配的字节范围 [start, end)
+type Span struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Span) Reset() {
+	*x = Span{}
+	mi := &file_searchpb_search_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Span) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Span) ProtoMessage() {}
+
+func (x *Span) ProtoReflect() protoreflect.Message {
+	mi := &file_searchpb_search_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Span.ProtoReflect.Descriptor instead.
+func (*Span) Descriptor() ([]byte, []int) {
+	return file_searchpb_search_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Span) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Span) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// FeedError 搜索失败的数据源，在所有结果之后发送
+type FeedError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Feed          string                 `protobuf:"bytes,1,opt,name=feed,proto3" json:"feed,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeedError) Reset() {
+	*x = FeedError{}
+	mi := &file_searchpb_search_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeedError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeedError) ProtoMessage() {}
+
+func (x *FeedError) ProtoReflect() protoreflect.Message {
+	mi := &file_searchpb_search_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeedError.ProtoReflect.Descriptor instead.
+func (*FeedError) Descriptor() ([]byte, []int) {
+	return file_searchpb_search_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FeedError) GetFeed() string {
+	if x != nil {
+		return x.Feed
+	}
+	return ""
+}
+
+func (x *FeedError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_searchpb_search_proto protoreflect.FileDescriptor
+
+const file_searchpb_search_proto_rawDesc = "" +
+	"\n" +
+	"\x15searchpb/search.proto\x12\rsearchinfo.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"p\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1f\n" +
+	"\vmax_results\x18\x02 \x01(\x05R\n" +
+	"maxResults\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x14\n" +
+	"\x05types\x18\x04 \x03(\tR\x05types\"\x85\x01\n" +
+	"\x0eSearchResponse\x12/\n" +
+	"\x06result\x18\x01 \x01(\v2\x15.searchinfo.v1.ResultH\x00R\x06result\x129\n" +
+	"\n" +
+	"feed_error\x18\x02 \x01(\v2\x18.searchinfo.v1.FeedErrorH\x00R\tfeedErrorB\a\n" +
+	"\x05event\"\x81\x02\n" +
+	"\x06Result\x12\x12\n" +
+	"\x04feed\x18\x01 \x01(\tR\x04feed\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
+	"\x05field\x18\x03 \x01(\tR\x05field\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x10\n" +
+	"\x03url\x18\x05 \x01(\tR\x03url\x12.\n" +
+	"\x04time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05score\x18\a \x01(\x01R\x05score\x12-\n" +
+	"\amatches\x18\b \x03(\v2\x13.searchinfo.v1.SpanR\amatches\x12\x18\n" +
+	"\asources\x18\t \x03(\tR\asources\".\n" +
+	"\x04Span\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\"5\n" +
+	"\tFeedError\x12\x12\n" +
+	"\x04feed\x18\x01 \x01(\tR\x04feed\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error2X\n" +
+	"\rSearchService\x12G\n" +
+	"\x06Search\x12\x1c.searchinfo.v1.SearchRequest\x1a\x1d.searchinfo.v1.SearchResponse0\x01BAZ?github.com/binarycoder777/mini-go-demo/demo/searchInfo/searchpbb\x06proto3"
+
+var (
+	file_searchpb_search_proto_rawDescOnce sync.Once
+	file_searchpb_search_proto_rawDescData []byte
+)
+
+func file_searchpb_search_proto_rawDescGZIP() []byte {
+	file_searchpb_search_proto_rawDescOnce.Do(func() {
+		file_searchpb_search_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_searchpb_search_proto_rawDesc), len(file_searchpb_search_proto_rawDesc)))
+	})
+	return file_searchpb_search_proto_rawDescData
+}
+
+var file_searchpb_search_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_searchpb_search_proto_goTypes = []any{
+	(*SearchRequest)(nil),         // 0: searchinfo.v1.SearchRequest
+	(*SearchResponse)(nil),        // 1: searchinfo.v1.SearchResponse
+	(*Result)(nil),                // 2: searchinfo.v1.Result
+	(*Span)(nil),                  // 3: searchinfo.v1.Span
+	(*FeedError)(nil),             // 4: searchinfo.v1.FeedError
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_searchpb_search_proto_depIdxs = []int32{
+	2, // 0: searchinfo.v1.SearchResponse.result:type_name -> searchinfo.v1.Result
+	4, // 1: searchinfo.v1.SearchResponse.feed_error:type_name -> searchinfo.v1.FeedError
+	5, // 2: searchinfo.v1.Result.time:type_name -> google.protobuf.Timestamp
+	3, // 3: searchinfo.v1.Result.matches:type_name -> searchinfo.v1.Span
+	0, // 4: searchinfo.v1.SearchService.Search:input_type -> searchinfo.v1.SearchRequest
+	1, // 5: searchinfo.v1.SearchService.Search:output_type -> searchinfo.v1.SearchResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_searchpb_search_proto_init() }
+func file_searchpb_search_proto_init() {
+	if File_searchpb_search_proto != nil {
+		return
+	}
+	file_searchpb_search_proto_msgTypes[1].OneofWrappers = []any{
+		(*SearchResponse_Result)(nil),
+		(*SearchResponse_FeedError)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_searchpb_search_proto_rawDesc), len(file_searchpb_search_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_searchpb_search_proto_goTypes,
+		DependencyIndexes: file_searchpb_search_proto_depIdxs,
+		MessageInfos:      file_searchpb_search_proto_msgTypes,
+	}.Build()
+	File_searchpb_search_proto = out.File
+	file_searchpb_search_proto_goTypes = nil
+	file_searchpb_search_proto_depIdxs = nil
+}
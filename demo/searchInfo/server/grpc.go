@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/searchpb"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPC 以 gRPC 提供搜索，实现 searchpb.SearchServiceServer：
+//
+//	s := grpc.NewServer()
+//	searchpb.RegisterSearchServiceServer(s, server.NewGRPC(opts...))
+type GRPC struct {
+	searchpb.UnimplementedSearchServiceServer
+	opts []search.Option
+
+	// BaseContext 不为空时，它被取消后进行中的搜索随之取消，
+	// 与 http.Server 的 BaseContext 相同，用于退出前结束所有的流
+	BaseContext context.Context
+}
+
+// NewGRPC 创建 GRPC，opts 用于每一次搜索，请求指定的选项附加在其后
+func NewGRPC(opts ...search.Option) *GRPC {
+	return &GRPC{opts: opts}
+}
+
+// Search 实现 searchpb.SearchServiceServer
+// 每个结果在到达时即发送，失败的数据源在所有结果之后发送；客户端取消时搜索随之取消
+func (g *GRPC) Search(req *searchpb.SearchRequest, stream searchpb.SearchService_SearchServer) error {
+	ctx := stream.Context()
+	if g.BaseContext != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(g.BaseContext, cancel)()
+	}
+	term := strings.TrimSpace(req.GetQuery())
+	if term == "" {
+		return status.Error(codes.InvalidArgument, "missing query")
+	}
+	if req.GetMaxResults() < 0 {
+		return status.Error(codes.InvalidArgument, "bad max_results")
+	}
+
+	opts := append([]search.Option(nil), g.opts...)
+	if n := req.GetMaxResults(); n > 0 {
+		opts = append(opts, search.WithMaxResults(int(n)))
+	}
+	if tags := req.GetTags(); len(tags) > 0 {
+		opts = append(opts, search.WithTags(tags...))
+	}
+	if types := req.GetTypes(); len(types) > 0 {
+		opts = append(opts, search.WithMatchersOnly(types...))
+	}
+
+	d := &streamDisplayer{stream: stream}
+	err := search.Run(ctx, term, append(opts, search.WithDisplayer(d))...)
+	var feedErrs search.Errors
+	switch {
+	case errors.As(err, &feedErrs):
+		// 部分数据源失败时仍返回其余的结果，并逐个发送失败的数据源
+		for _, e := range feedErrs {
+			slog.WarnContext(ctx, "search feed", "feed", e.Feed.Name, "error", e.Err)
+			resp := &searchpb.SearchResponse{Event: &searchpb.SearchResponse_FeedError{
+				FeedError: &searchpb.FeedError{Feed: e.Feed.Name, Error: e.Err.Error()},
+			}}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	case err != nil && ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case err != nil && d.sent == 0:
+		// 还没有发送任何结果，例如查询语句无法解析
+		return status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// streamDisplayer 把每个结果作为一条消息发送到流中
+type streamDisplayer struct {
+	stream searchpb.SearchService_SearchServer
+	sent   int
+}
+
+func (d *streamDisplayer) Display(results <-chan *search.Result) error {
+	for result := range results {
+		resp := &searchpb.SearchResponse{Event: &searchpb.SearchResponse_Result{Result: toProto(result)}}
+		if err := d.stream.Send(resp); err != nil {
+			return err
+		}
+		d.sent++
+	}
+	return nil
+}
+
+// toProto 把 search.Result 转换为 searchpb.Result
+func toProto(result *search.Result) *searchpb.Result {
+	r := &searchpb.Result{
+		Field:   result.Field,
+		Content: result.Content,
+		Url:     result.URL,
+		Score:   result.Score,
+		Sources: result.Sources,
+	}
+	if result.Feed != nil {
+		r.Feed = result.Feed.Name
+		r.Type = result.Feed.Type
+	}
+	if !result.Time.IsZero() {
+		r.Time = timestamppb.New(result.Time)
+	}
+	for _, m := range result.Matches {
+		r.Matches = append(r.Matches, &searchpb.Span{Start: int32(m.Start), End: int32(m.End)})
+	}
+	return r
+}
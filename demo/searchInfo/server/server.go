@@ -0,0 +1,188 @@
+// Package server 以 HTTP 接口提供搜索：
+//
+//	GET /search?q=搜索词&format=json
+//
+// 结果在到达时即流式返回，客户端断开后搜索随之取消
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Server 处理搜索请求的 http.Handler
+type Server struct {
+	opts []search.Option
+	mux  *http.ServeMux
+}
+
+// New 创建 Server，opts 用于每一次搜索，请求参数指定的选项附加在其后
+func New(opts ...search.Option) *Server {
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/search", s.search)
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	return s
+}
+
+// Handle 在 Server 上挂载其他的 Handler，例如 /metrics
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// search 处理 GET /search，支持以下参数：
+//
+//	q       搜索词，必需
+//	format  json（默认，每行一个 JSON 对象）、sse、csv、table 或 plain；
+//	        请求头 Accept 为 text/event-stream 时默认为 sse
+//	max     得到这么多结果后停止搜索
+//	tag     只搜索带有该标签的数据源，可以重复
+//	type    只搜索该类型的数据源，可以重复
+//	sort    排序依据，如 score、time，可以重复
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	term := strings.TrimSpace(params.Get("q"))
+	if term == "" {
+		http.Error(w, "missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	opts := append([]search.Option(nil), s.opts...)
+	if max := params.Get("max"); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil || n < 0 {
+			http.Error(w, "bad max parameter", http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, search.WithMaxResults(n))
+	}
+	if tags := params["tag"]; len(tags) > 0 {
+		opts = append(opts, search.WithTags(tags...))
+	}
+	if types := params["type"]; len(types) > 0 {
+		opts = append(opts, search.WithMatchersOnly(types...))
+	}
+	if names := params["sort"]; len(names) > 0 {
+		var keys []search.SortKey
+		for _, name := range names {
+			key, err := search.ParseSortKey(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			keys = append(keys, key)
+		}
+		opts = append(opts, search.WithSort(keys...))
+	}
+
+	format := params.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		format = "sse"
+	}
+	out := &responseWriter{w: w}
+	var displayer search.Displayer
+	switch format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+		displayer = &search.JSONDisplayer{W: out}
+	case "sse":
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		displayer = &search.JSONDisplayer{W: &sseWriter{w: out}}
+	default:
+		d, err := search.NewDisplayer(format, out)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		displayer = d
+	}
+
+	err := search.Run(r.Context(), term, append(opts, search.WithDisplayer(displayer))...)
+	var feedErrs search.Errors
+	switch {
+	case errors.As(err, &feedErrs):
+		// 部分数据源失败时仍返回其余的结果，SSE 额外发送失败的数据源
+		for _, e := range feedErrs {
+			slog.WarnContext(r.Context(), "search feed", "feed", e.Feed.Name, "error", e.Err)
+		}
+		if format == "sse" {
+			for _, e := range feedErrs {
+				writeEvent(out, "error", map[string]string{"feed": e.Feed.Name, "error": e.Err.Error()})
+			}
+		}
+	case err != nil && !out.written:
+		// 还没有输出任何结果，例如查询语句无法解析
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "search", "term", term, "error", err)
+	}
+	if format == "sse" {
+		writeEvent(out, "done", map[string]int{"failed": len(feedErrs)})
+	}
+}
+
+// responseWriter 把每次写入立即发送给客户端，并记录是否已经写入
+type responseWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	rw.written = true
+	n, err := rw.w.Write(p)
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+// sseWriter 把 JSONDisplayer 每次写入的一行 JSON 包装成一个 result 事件
+type sseWriter struct {
+	w io.Writer
+}
+
+func (s *sseWriter) Write(p []byte) (int, error) {
+	var b bytes.Buffer
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "event: result\ndata: %s\n\n", line)
+	}
+	if _, err := s.w.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeEvent 发送一个 SSE 事件，v 编码为 JSON
+func writeEvent(w io.Writer, event string, v interface{}) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/binarycoder777/mini-go-demo/demo/searchInfo/search"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// 按键
+const (
+	keyNone = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyBack
+	keyQuit
+	keyRefine
+)
+
+// browser 交互式浏览搜索结果的终端界面
+// 列表中用方向键选择结果，回车查看完整内容，/ 修改搜索词，q 退出
+type browser struct {
+	ctx    context.Context
+	in     *bufio.Reader
+	out    io.Writer
+	opts   []search.Option
+	term   string
+	status string
+
+	results  []*search.Result
+	selected int
+	top      int
+}
+
+// runInteractive 在终端中交互式地搜索和浏览结果
+func runInteractive(ctx context.Context, searchTerm string, opts ...search.Option) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("interactive mode needs a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	b := &browser{
+		ctx:  ctx,
+		in:   bufio.NewReader(os.Stdin),
+		out:  os.Stdout,
+		opts: opts,
+		term: searchTerm,
+	}
+	// 切换到备用屏幕，退出时恢复原来的内容
+	fmt.Fprint(b.out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(b.out, "\x1b[?25h\x1b[?1049l")
+
+	b.search()
+	for {
+		b.drawList()
+		switch b.readKey() {
+		case keyUp:
+			b.move(-1)
+		case keyDown:
+			b.move(1)
+		case keyPageUp:
+			b.move(-b.pageSize())
+		case keyPageDown:
+			b.move(b.pageSize())
+		case keyEnter:
+			if len(b.results) > 0 {
+				if !b.view(b.results[b.selected]) {
+					return nil
+				}
+			}
+		case keyRefine:
+			if refined, ok := b.prompt("/", b.term); ok && strings.TrimSpace(refined) != "" {
+				b.term = refined
+				b.search()
+			}
+		case keyQuit:
+			return nil
+		}
+	}
+}
+
+// search 用当前的搜索词重新搜索
+func (b *browser) search() {
+	b.clear()
+	fmt.Fprintf(b.out, "正在搜索 %q …", b.term)
+
+	b.results, b.selected, b.top = nil, 0, 0
+	results, err := search.Results(b.ctx, b.term, b.opts...)
+	b.results = results
+	b.status = fmt.Sprintf("%q: %d 条结果", b.term, len(results))
+
+	var feedErrs search.Errors
+	switch {
+	case errors.As(err, &feedErrs):
+		b.status += fmt.Sprintf("，%d 个数据源出错", len(feedErrs))
+	case err != nil:
+		b.status = err.Error()
+	}
+}
+
+// move 移动选中的结果，并让它保持在可见范围内
+func (b *browser) move(delta int) {
+	b.selected += delta
+	if b.selected >= len(b.results) {
+		b.selected = len(b.results) - 1
+	}
+	if b.selected < 0 {
+		b.selected = 0
+	}
+	if b.selected < b.top {
+		b.top = b.selected
+	}
+	if page := b.pageSize(); b.selected >= b.top+page {
+		b.top = b.selected - page + 1
+	}
+}
+
+// drawList 绘制结果列表，最后一行是状态栏
+func (b *browser) drawList() {
+	width, _ := b.size()
+	b.clear()
+	for i := b.top; i < len(b.results) && i < b.top+b.pageSize(); i++ {
+		result := b.results[i]
+		line := truncate(fmt.Sprintf("[%s] %s: %s", feedName(result), result.Field, oneLine(result.Content)), width-2)
+		if i == b.selected {
+			fmt.Fprintf(b.out, "\x1b[7m> %s\x1b[0m\r\n", line)
+		} else {
+			fmt.Fprintf(b.out, "  %s\r\n", line)
+		}
+	}
+	b.statusLine(b.status + "  ↑↓ 选择  回车 查看  / 搜索  q 退出")
+}
+
+// view 显示结果的完整内容，返回 false 表示用户要求退出
+func (b *browser) view(result *search.Result) bool {
+	header := []string{
+		fmt.Sprintf("数据源: %s", feedName(result)),
+		fmt.Sprintf("字段:   %s", result.Field),
+	}
+	if result.URL != "" {
+		header = append(header, "链接:   "+result.URL)
+	}
+	if !result.Time.IsZero() {
+		header = append(header, "时间:   "+result.Time.Format("2006-01-02 15:04"))
+	}
+	content := search.ANSIMarkers.Apply(result.Content, result.Matches)
+
+	top := 0
+	for {
+		width, _ := b.size()
+		lines := append(append([]string{}, header...), "")
+		for _, l := range strings.Split(content, "\n") {
+			lines = append(lines, wrap(l, width)...)
+		}
+		page := b.pageSize()
+		if top > len(lines)-page {
+			top = len(lines) - page
+		}
+		if top < 0 {
+			top = 0
+		}
+
+		b.clear()
+		for i := top; i < len(lines) && i < top+page; i++ {
+			fmt.Fprintf(b.out, "%s\x1b[0m\r\n", lines[i])
+		}
+		b.statusLine("↑↓ 滚动  Esc 返回  q 退出")
+
+		switch b.readKey() {
+		case keyUp:
+			top--
+		case keyDown:
+			top++
+		case keyPageUp:
+			top -= page
+		case keyPageDown:
+			top += page
+		case keyBack, keyEnter:
+			return true
+		case keyQuit:
+			return false
+		}
+	}
+}
+
+// prompt 在状态栏读入一行文字，Esc 取消
+func (b *browser) prompt(label, value string) (string, bool) {
+	line := []rune(value)
+	fmt.Fprint(b.out, "\x1b[?25h")
+	defer fmt.Fprint(b.out, "\x1b[?25l")
+	for {
+		b.statusLine(label + string(line))
+		r, _, err := b.in.ReadRune()
+		if err != nil {
+			return "", false
+		}
+		switch r {
+		case '\r', '\n':
+			return string(line), true
+		case 0x1b, 0x03:
+			b.discardEscape()
+			return "", false
+		case 0x7f, 0x08:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case 0x15: // Ctrl-U 清空
+			line = line[:0]
+		default:
+			if r >= ' ' {
+				line = append(line, r)
+			}
+		}
+	}
+}
+
+// readKey 读入一个按键
+func (b *browser) readKey() int {
+	r, _, err := b.in.ReadRune()
+	if err != nil {
+		return keyQuit
+	}
+	switch r {
+	case 'k':
+		return keyUp
+	case 'j':
+		return keyDown
+	case ' ':
+		return keyPageDown
+	case '\r', '\n':
+		return keyEnter
+	case '/':
+		return keyRefine
+	case 'q', 0x03:
+		return keyQuit
+	case 0x7f, 0x08:
+		return keyBack
+	case 0x1b:
+		return b.readEscape()
+	}
+	return keyNone
+}
+
+// readEscape 解析 Esc 之后的转义序列，单独的 Esc 表示返回
+func (b *browser) readEscape() int {
+	if b.in.Buffered() == 0 {
+		return keyBack
+	}
+	if c, _ := b.in.ReadByte(); c != '[' && c != 'O' {
+		return keyNone
+	}
+	c, _ := b.in.ReadByte()
+	switch c {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case '5', '6':
+		b.in.ReadByte() // '~'
+		if c == '5' {
+			return keyPageUp
+		}
+		return keyPageDown
+	}
+	return keyNone
+}
+
+// discardEscape 丢弃缓冲区中剩余的转义序列
+func (b *browser) discardEscape() {
+	b.in.Discard(b.in.Buffered())
+}
+
+// clear 清屏并把光标移到左上角
+func (b *browser) clear() {
+	fmt.Fprint(b.out, "\x1b[H\x1b[2J")
+}
+
+// statusLine 在最后一行显示 s
+func (b *browser) statusLine(s string) {
+	width, height := b.size()
+	fmt.Fprintf(b.out, "\x1b[%d;1H\x1b[2K\x1b[7m%s\x1b[0m", height, truncate(s, width))
+}
+
+// size 返回终端的宽和高
+func (b *browser) size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// pageSize 返回一屏可以显示的行数，不含状态栏
+func (b *browser) pageSize() int {
+	_, height := b.size()
+	if height < 2 {
+		return 1
+	}
+	return height - 1
+}
+
+// feedName 返回结果所属数据源的名称
+func feedName(result *search.Result) string {
+	if result.Feed == nil {
+		return ""
+	}
+	return result.Feed.Name
+}
+
+// oneLine 把多行内容合并为一行
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate 把 s 截断到最多 width 个字符
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + search.Ellipsis
+}
+
+// wrap 把一行按 width 个字符折行，跳过 ANSI 转义序列不计宽度
+func wrap(s string, width int) []string {
+	if width <= 0 {
+		return []string{s}
+	}
+	var lines []string
+	var line strings.Builder
+	n := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			j := strings.IndexByte(s[i:], 'm')
+			if j < 0 {
+				j = len(s) - i - 1
+			}
+			line.WriteString(s[i : i+j+1])
+			i += j + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if n == width {
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		line.WriteRune(r)
+		n++
+		i += size
+	}
+	return append(lines, line.String())
+}